@@ -0,0 +1,673 @@
+// Couchbase, Inc. licenses this to you under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at https://www.apache.org/licenses/LICENSE-2.0.
+
+// Unless required by applicable law or agreed to in writing, software distributed under the License
+// is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	couchbasecapella "github.com/couchbasecloud/couchbase-capella-api-go-client"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// crudTestCase is a call to a CRUD function of a resource against a fakeCapellaClient.
+type crudTestCase struct {
+	name string
+	// setup prepares the fake client and returns the id of the resource the
+	// CRUD function is called for, or an empty string for a new resource.
+	setup func(f *fakeCapellaClient) string
+	raw   map[string]interface{}
+	crud  func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics
+	// wantErr is the start of the summary of the error the CRUD function fails with,
+	// or empty when it must succeed.
+	wantErr string
+	check   func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData)
+}
+
+// runCRUDTestCases is responsible for running the CRUD test cases of a resource.
+func runCRUDTestCases(t *testing.T, r *schema.Resource, testCases []crudTestCase) {
+	shortenPollIntervals(t)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeCapellaClient()
+			d := schema.TestResourceDataRaw(t, r.Schema, tc.raw)
+			if tc.setup != nil {
+				d.SetId(tc.setup(f))
+			}
+
+			diags := tc.crud(context.Background(), d, f)
+			if tc.wantErr == "" && diags.HasError() {
+				t.Fatalf("err: %v", diags)
+			}
+			if tc.wantErr != "" && (!diags.HasError() || !strings.HasPrefix(diags[0].Summary, tc.wantErr)) {
+				t.Fatalf("expected an error starting with %q, got %v", tc.wantErr, diags)
+			}
+			if tc.check != nil {
+				tc.check(t, f, d)
+			}
+		})
+	}
+}
+
+// fakeCapellaClient is an in-memory capellaClient for the unit tests of the CRUD
+// functions. Its clusters are ready as soon as they are created and gone as soon
+// as they are deleted, and any operation can be made to fail through errs.
+type fakeCapellaClient struct {
+	projectID string
+
+	projects       map[string]couchbasecapella.Project
+	clouds         map[string]couchbasecapella.Cloud
+	vpcClusters    map[string]vpcCluster
+	hostedClusters map[string]hostedCluster
+	// vpcStatuses are the statuses each vpc cluster reports, one per read of its
+	// status, before it reports its own status.
+	vpcStatuses map[string][]couchbasecapella.ClusterStatus
+	// buckets and users are the buckets and database users of each cluster.
+	buckets map[string][]bucketSpec
+	users   map[string][]couchbasecapella.ListDatabaseUsersResponseItem
+
+	// errs maps the name of an operation, such as "CreateProject", to the status
+	// code of the error response it fails with.
+	errs map[string]int
+	// calls are the names of the operations called, in order.
+	calls []string
+}
+
+var _ capellaClient = (*fakeCapellaClient)(nil)
+
+func newFakeCapellaClient() *fakeCapellaClient {
+	return &fakeCapellaClient{
+		projects:       make(map[string]couchbasecapella.Project),
+		clouds:         make(map[string]couchbasecapella.Cloud),
+		vpcClusters:    make(map[string]vpcCluster),
+		hostedClusters: make(map[string]hostedCluster),
+		vpcStatuses:    make(map[string][]couchbasecapella.ClusterStatus),
+		buckets:        make(map[string][]bucketSpec),
+		users:          make(map[string][]couchbasecapella.ListDatabaseUsersResponseItem),
+		errs:           make(map[string]int),
+	}
+}
+
+// call records a call to an operation, returning the error response it fails with if any.
+func (f *fakeCapellaClient) call(operation string) (*http.Response, error) {
+	f.calls = append(f.calls, operation)
+	if status, ok := f.errs[operation]; ok {
+		return fakeErrorResponse(status, fmt.Sprintf("%s failed", operation))
+	}
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}, nil
+}
+
+// called reports whether an operation has been called.
+func (f *fakeCapellaClient) called(operation string) bool {
+	for _, call := range f.calls {
+		if call == operation {
+			return true
+		}
+	}
+	return false
+}
+
+// fakeErrorResponse is responsible for building an error response of the Capella API.
+func fakeErrorResponse(status int, message string) (*http.Response, error) {
+	body := fmt.Sprintf(`{"message":%q,"errorType":%q}`, message, strings.ReplaceAll(http.StatusText(status), " ", ""))
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}, errors.New(http.StatusText(status))
+}
+
+func (f *fakeCapellaClient) notFound(operation, kind, id string) (*http.Response, error) {
+	f.calls = append(f.calls, operation)
+	return fakeErrorResponse(http.StatusNotFound, fmt.Sprintf("%s %s not found", kind, id))
+}
+
+func (f *fakeCapellaClient) DefaultProjectID() string {
+	return f.projectID
+}
+
+func (f *fakeCapellaClient) CreateProject(ctx context.Context, request couchbasecapella.CreateProjectRequest) (couchbasecapella.Project, *http.Response, error) {
+	r, err := f.call("CreateProject")
+	if err != nil {
+		return couchbasecapella.Project{}, r, err
+	}
+	project := couchbasecapella.Project{Id: fmt.Sprintf("project-%d", len(f.projects)+1), Name: request.Name}
+	f.projects[project.Id] = project
+	return project, r, nil
+}
+
+func (f *fakeCapellaClient) GetProject(ctx context.Context, projectId string) (couchbasecapella.Project, *http.Response, error) {
+	project, ok := f.projects[projectId]
+	if !ok {
+		r, err := f.notFound("GetProject", "project", projectId)
+		return project, r, err
+	}
+	r, err := f.call("GetProject")
+	return project, r, err
+}
+
+func (f *fakeCapellaClient) DeleteProject(ctx context.Context, projectId string) (*http.Response, error) {
+	if _, ok := f.projects[projectId]; !ok {
+		return f.notFound("DeleteProject", "project", projectId)
+	}
+	r, err := f.call("DeleteProject")
+	if err == nil {
+		delete(f.projects, projectId)
+	}
+	return r, err
+}
+
+func (f *fakeCapellaClient) GetCloud(ctx context.Context, cloudId string) (couchbasecapella.Cloud, *http.Response, error) {
+	cloud, ok := f.clouds[cloudId]
+	if !ok {
+		r, err := f.notFound("GetCloud", "cloud", cloudId)
+		return cloud, r, err
+	}
+	r, err := f.call("GetCloud")
+	return cloud, r, err
+}
+
+func (f *fakeCapellaClient) CreateVpcCluster(ctx context.Context, request vpcClusterRequest) (string, *http.Response, error) {
+	r, err := f.call("CreateVpcCluster")
+	if err != nil {
+		return "", r, err
+	}
+	id := fmt.Sprintf("vpc-cluster-%d", len(f.vpcClusters)+1)
+	cluster := vpcCluster{
+		Cluster: couchbasecapella.Cluster{
+			Id:           id,
+			Name:         request.Name,
+			CloudId:      request.CloudId,
+			ProjectId:    request.ProjectId,
+			Status:       couchbasecapella.CLUSTERSTATUS_READY,
+			Version:      &couchbasecapella.ClusterVersion{Name: "7.1.1", Components: map[string]string{"cbServerVersion": "7.1.1"}},
+			EndpointsURL: &[]string{"https://cb." + id + ".cloud.couchbase.com"},
+			EndpointsSrv: couchbasecapella.PtrString("cb." + id + ".cloud.couchbase.com"),
+		},
+		Servers: request.Servers,
+	}
+	f.vpcClusters[cluster.Id] = cluster
+	return cluster.Id, r, nil
+}
+
+func (f *fakeCapellaClient) GetVpcCluster(ctx context.Context, clusterId string) (vpcCluster, *http.Response, error) {
+	cluster, ok := f.vpcClusters[clusterId]
+	if !ok {
+		r, err := f.notFound("GetVpcCluster", "cluster", clusterId)
+		return cluster, r, err
+	}
+	r, err := f.call("GetVpcCluster")
+	return cluster, r, err
+}
+
+func (f *fakeCapellaClient) GetVpcClusterStatus(ctx context.Context, clusterId string) (couchbasecapella.ClusterStatusResponse, *http.Response, error) {
+	cluster, ok := f.vpcClusters[clusterId]
+	if !ok {
+		r, err := f.notFound("GetVpcClusterStatus", "cluster", clusterId)
+		return couchbasecapella.ClusterStatusResponse{}, r, err
+	}
+	r, err := f.call("GetVpcClusterStatus")
+	status := cluster.Status
+	if statuses := f.vpcStatuses[clusterId]; len(statuses) > 0 {
+		status, f.vpcStatuses[clusterId] = statuses[0], statuses[1:]
+	}
+	return couchbasecapella.ClusterStatusResponse{Status: status}, r, err
+}
+
+func (f *fakeCapellaClient) UpdateVpcClusterServers(ctx context.Context, clusterId string, servers []vpcServer) (*http.Response, error) {
+	cluster, ok := f.vpcClusters[clusterId]
+	if !ok {
+		return f.notFound("UpdateVpcClusterServers", "cluster", clusterId)
+	}
+	r, err := f.call("UpdateVpcClusterServers")
+	if err == nil {
+		cluster.Servers = servers
+		f.vpcClusters[clusterId] = cluster
+	}
+	return r, err
+}
+
+func (f *fakeCapellaClient) DeleteVpcCluster(ctx context.Context, clusterId string) (*http.Response, error) {
+	if _, ok := f.vpcClusters[clusterId]; !ok {
+		return f.notFound("DeleteVpcCluster", "cluster", clusterId)
+	}
+	r, err := f.call("DeleteVpcCluster")
+	if err == nil {
+		delete(f.vpcClusters, clusterId)
+	}
+	return r, err
+}
+
+func (f *fakeCapellaClient) CreateHostedCluster(ctx context.Context, request couchbasecapella.V3CreateClusterRequest) (string, *http.Response, error) {
+	r, err := f.call("CreateHostedCluster")
+	if err != nil {
+		return "", r, err
+	}
+	place := request.Place.GetHosted()
+	cluster := hostedCluster{
+		V3Cluster: couchbasecapella.V3Cluster{
+			Id:          fmt.Sprintf("hosted-cluster-%d", len(f.hostedClusters)+1),
+			Name:        request.ClusterName,
+			ProjectId:   request.ProjectId,
+			Status:      string(couchbasecapella.V3CLUSTERSTATUS_HEALTHY),
+			Environment: string(request.Environment),
+			Place:       couchbasecapella.V3ClusterPlace{Provider: string(place.Provider), Region: place.Region, CIDR: place.CIDR},
+			Servers:     fakeHostedClusterServers(request.Servers),
+			Support:     string(request.SupportPackage.Type),
+			Version: couchbasecapella.V3ClusterVersion{
+				Name:       "7.1.1",
+				Components: couchbasecapella.V3ClusterVersionComponents{CbServerVersion: "7.1.1"},
+			},
+			EndpointsSrv:      couchbasecapella.PtrString(fmt.Sprintf("cb.hosted-cluster-%d.cloud.couchbase.com", len(f.hostedClusters)+1)),
+			CreatedAt:         time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC),
+			UpdatedAt:         time.Date(2022, 8, 1, 12, 30, 0, 0, time.UTC),
+			AvailabilityZones: []string{place.Region + "a"},
+		},
+		Description: request.GetDescription(),
+		SingleAZ:    &request.Place.SingleAZ,
+		SupportPackage: &hostedSupportPackage{
+			Timezone: string(request.SupportPackage.Timezone),
+			Type:     string(request.SupportPackage.Type),
+		},
+	}
+	f.hostedClusters[cluster.Id] = cluster
+	return cluster.Id, r, nil
+}
+
+func (f *fakeCapellaClient) GetHostedCluster(ctx context.Context, clusterId string) (hostedCluster, *http.Response, error) {
+	cluster, ok := f.hostedClusters[clusterId]
+	if !ok {
+		r, err := f.notFound("GetHostedCluster", "cluster", clusterId)
+		return cluster, r, err
+	}
+	r, err := f.call("GetHostedCluster")
+	return cluster, r, err
+}
+
+func (f *fakeCapellaClient) GetHostedClusterStatus(ctx context.Context, clusterId string) (couchbasecapella.V3ClusterStatusResponse, *http.Response, error) {
+	cluster, ok := f.hostedClusters[clusterId]
+	if !ok {
+		r, err := f.notFound("GetHostedClusterStatus", "cluster", clusterId)
+		return couchbasecapella.V3ClusterStatusResponse{}, r, err
+	}
+	r, err := f.call("GetHostedClusterStatus")
+	return couchbasecapella.V3ClusterStatusResponse{Status: couchbasecapella.V3ClusterStatus(cluster.Status)}, r, err
+}
+
+func (f *fakeCapellaClient) UpdateHostedClusterMeta(ctx context.Context, clusterId string, request couchbasecapella.V3UpdateClusterMetaRequest) (*http.Response, error) {
+	cluster, ok := f.hostedClusters[clusterId]
+	if !ok {
+		return f.notFound("UpdateHostedClusterMeta", "cluster", clusterId)
+	}
+	r, err := f.call("UpdateHostedClusterMeta")
+	if err == nil {
+		cluster.Name, cluster.Description = request.GetName(), request.GetDescription()
+		f.hostedClusters[clusterId] = cluster
+	}
+	return r, err
+}
+
+func (f *fakeCapellaClient) UpdateHostedClusterSupport(ctx context.Context, clusterId string, request couchbasecapella.V3UpdateClusterSupportRequest) (*http.Response, error) {
+	cluster, ok := f.hostedClusters[clusterId]
+	if !ok {
+		return f.notFound("UpdateHostedClusterSupport", "cluster", clusterId)
+	}
+	r, err := f.call("UpdateHostedClusterSupport")
+	if err == nil {
+		cluster.Support = string(request.SupportPackage.Type)
+		cluster.SupportPackage = &hostedSupportPackage{Timezone: string(request.SupportPackage.GetTimezone()), Type: cluster.Support}
+		f.hostedClusters[clusterId] = cluster
+	}
+	return r, err
+}
+
+func (f *fakeCapellaClient) UpdateHostedClusterServers(ctx context.Context, clusterId string, request couchbasecapella.V3UpdateClusterServersRequest) (*http.Response, error) {
+	cluster, ok := f.hostedClusters[clusterId]
+	if !ok {
+		return f.notFound("UpdateHostedClusterServers", "cluster", clusterId)
+	}
+	r, err := f.call("UpdateHostedClusterServers")
+	if err == nil {
+		cluster.Servers = fakeHostedClusterServers(request.Servers)
+		f.hostedClusters[clusterId] = cluster
+	}
+	return r, err
+}
+
+func (f *fakeCapellaClient) DeleteHostedCluster(ctx context.Context, clusterId string) (*http.Response, error) {
+	if _, ok := f.hostedClusters[clusterId]; !ok {
+		return f.notFound("DeleteHostedCluster", "cluster", clusterId)
+	}
+	r, err := f.call("DeleteHostedCluster")
+	if err == nil {
+		delete(f.hostedClusters, clusterId)
+	}
+	return r, err
+}
+
+func (f *fakeCapellaClient) ListBuckets(ctx context.Context, clusterId string) ([]bucketSpec, *http.Response, error) {
+	if _, ok := f.vpcClusters[clusterId]; !ok {
+		r, err := f.notFound("ListBuckets", "cluster", clusterId)
+		return nil, r, err
+	}
+	r, err := f.call("ListBuckets")
+	if err != nil {
+		return nil, r, err
+	}
+	return f.buckets[clusterId], r, nil
+}
+
+func (f *fakeCapellaClient) CreateBucket(ctx context.Context, clusterId string, spec bucketSpec) (*http.Response, error) {
+	if _, ok := f.vpcClusters[clusterId]; !ok {
+		return f.notFound("CreateBucket", "cluster", clusterId)
+	}
+	r, err := f.call("CreateBucket")
+	if err != nil {
+		return r, err
+	}
+	spec.Id = spec.Name
+	f.buckets[clusterId] = append(f.buckets[clusterId], spec)
+	return r, nil
+}
+
+func (f *fakeCapellaClient) UpdateBucket(ctx context.Context, clusterId, bucketId string, spec bucketSpec) (*http.Response, error) {
+	// Like the v2 endpoint, only the memory quota of the bucket is updated.
+	return f.updateBucket("UpdateBucket", clusterId, bucketId, func(bucket *bucketSpec) {
+		bucket.MemoryQuota = spec.MemoryQuota
+	})
+}
+
+func (f *fakeCapellaClient) UpdateHostedBucket(ctx context.Context, clusterId, bucketId string, spec bucketSpec) (*http.Response, error) {
+	return f.updateBucket("UpdateHostedBucket", clusterId, bucketId, func(bucket *bucketSpec) {
+		bucket.MemoryQuota = spec.MemoryQuota
+		bucket.Replicas = spec.Replicas
+		bucket.DurabilityLevel = spec.DurabilityLevel
+		bucket.MaxTTL = spec.MaxTTL
+		bucket.FlushEnabled = spec.FlushEnabled
+	})
+}
+
+// updateBucket is responsible for changing the settings of a bucket of the fake.
+func (f *fakeCapellaClient) updateBucket(operation, clusterId, bucketId string, update func(bucket *bucketSpec)) (*http.Response, error) {
+	for i, bucket := range f.buckets[clusterId] {
+		if bucket.Id == bucketId {
+			r, err := f.call(operation)
+			if err == nil {
+				update(&f.buckets[clusterId][i])
+			}
+			return r, err
+		}
+	}
+	return f.notFound(operation, "bucket", bucketId)
+}
+
+func (f *fakeCapellaClient) DeleteBucket(ctx context.Context, clusterId string, request couchbasecapella.DeleteBucketRequest) (*http.Response, error) {
+	for i, bucket := range f.buckets[clusterId] {
+		if bucket.Name == request.Name {
+			r, err := f.call("DeleteBucket")
+			if err == nil {
+				f.buckets[clusterId] = append(f.buckets[clusterId][:i:i], f.buckets[clusterId][i+1:]...)
+			}
+			return r, err
+		}
+	}
+	return f.notFound("DeleteBucket", "bucket", request.Name)
+}
+
+func (f *fakeCapellaClient) ListHostedBuckets(ctx context.Context, clusterId string) ([]bucketSpec, *http.Response, error) {
+	if _, ok := f.hostedClusters[clusterId]; !ok {
+		r, err := f.notFound("ListHostedBuckets", "cluster", clusterId)
+		return nil, r, err
+	}
+	r, err := f.call("ListHostedBuckets")
+	if err != nil {
+		return nil, r, err
+	}
+	return f.buckets[clusterId], r, nil
+}
+
+func (f *fakeCapellaClient) CreateHostedBucket(ctx context.Context, clusterId string, spec bucketSpec) (*http.Response, error) {
+	if _, ok := f.hostedClusters[clusterId]; !ok {
+		return f.notFound("CreateHostedBucket", "cluster", clusterId)
+	}
+	r, err := f.call("CreateHostedBucket")
+	if err != nil {
+		return r, err
+	}
+	spec.Id = "id-" + spec.Name
+	f.buckets[clusterId] = append(f.buckets[clusterId], spec)
+	return r, nil
+}
+
+func (f *fakeCapellaClient) DeleteHostedBucket(ctx context.Context, clusterId, bucketId string) (*http.Response, error) {
+	for i, bucket := range f.buckets[clusterId] {
+		if bucket.Id == bucketId {
+			r, err := f.call("DeleteHostedBucket")
+			if err == nil {
+				f.buckets[clusterId] = append(f.buckets[clusterId][:i:i], f.buckets[clusterId][i+1:]...)
+			}
+			return r, err
+		}
+	}
+	return f.notFound("DeleteHostedBucket", "bucket", bucketId)
+}
+
+func (f *fakeCapellaClient) ListDatabaseUsers(ctx context.Context, clusterId string) ([]couchbasecapella.ListDatabaseUsersResponseItem, *http.Response, error) {
+	if _, ok := f.vpcClusters[clusterId]; !ok {
+		r, err := f.notFound("ListDatabaseUsers", "cluster", clusterId)
+		return nil, r, err
+	}
+	r, err := f.call("ListDatabaseUsers")
+	if err != nil {
+		return nil, r, err
+	}
+	return f.users[clusterId], r, nil
+}
+
+func (f *fakeCapellaClient) CreateDatabaseUser(ctx context.Context, clusterId string, request couchbasecapella.CreateDatabaseUserRequest) (*http.Response, error) {
+	if _, ok := f.vpcClusters[clusterId]; !ok {
+		return f.notFound("CreateDatabaseUser", "cluster", clusterId)
+	}
+	r, err := f.call("CreateDatabaseUser")
+	if err != nil {
+		return r, err
+	}
+	f.users[clusterId] = append(f.users[clusterId], couchbasecapella.ListDatabaseUsersResponseItem{
+		Username: request.Username,
+		Access:   request.GetBuckets(),
+	})
+	return r, nil
+}
+
+func (f *fakeCapellaClient) UpdateDatabaseUser(ctx context.Context, clusterId, username string, request couchbasecapella.UpdateDatabaseUserRequest) (*http.Response, error) {
+	for i, user := range f.users[clusterId] {
+		if user.Username == username {
+			r, err := f.call("UpdateDatabaseUser")
+			if err == nil && request.Buckets != nil {
+				f.users[clusterId][i].Access = *request.Buckets
+			}
+			return r, err
+		}
+	}
+	return f.notFound("UpdateDatabaseUser", "user", username)
+}
+
+func (f *fakeCapellaClient) DeleteDatabaseUser(ctx context.Context, clusterId, username string) (*http.Response, error) {
+	for i, user := range f.users[clusterId] {
+		if user.Username == username {
+			r, err := f.call("DeleteDatabaseUser")
+			if err == nil {
+				f.users[clusterId] = append(f.users[clusterId][:i:i], f.users[clusterId][i+1:]...)
+			}
+			return r, err
+		}
+	}
+	return f.notFound("DeleteDatabaseUser", "user", username)
+}
+
+func (f *fakeCapellaClient) ListHostedDatabaseUsers(ctx context.Context, clusterId string) ([]couchbasecapella.ListDatabaseUsersResponseItem, *http.Response, error) {
+	if _, ok := f.hostedClusters[clusterId]; !ok {
+		r, err := f.notFound("ListHostedDatabaseUsers", "cluster", clusterId)
+		return nil, r, err
+	}
+	r, err := f.call("ListHostedDatabaseUsers")
+	if err != nil {
+		return nil, r, err
+	}
+	return f.users[clusterId], r, nil
+}
+
+func (f *fakeCapellaClient) CreateHostedDatabaseUser(ctx context.Context, clusterId string, request couchbasecapella.CreateDatabaseUserRequest) (*http.Response, error) {
+	if _, ok := f.hostedClusters[clusterId]; !ok {
+		return f.notFound("CreateHostedDatabaseUser", "cluster", clusterId)
+	}
+	r, err := f.call("CreateHostedDatabaseUser")
+	if err != nil {
+		return r, err
+	}
+	f.users[clusterId] = append(f.users[clusterId], couchbasecapella.ListDatabaseUsersResponseItem{
+		UserId:   couchbasecapella.PtrString("id-" + request.Username),
+		Username: request.Username,
+		Access:   request.GetBuckets(),
+	})
+	return r, nil
+}
+
+func (f *fakeCapellaClient) UpdateHostedDatabaseUser(ctx context.Context, clusterId, userId string, request couchbasecapella.UpdateDatabaseUserRequest) (*http.Response, error) {
+	for i, user := range f.users[clusterId] {
+		if user.GetUserId() == userId {
+			r, err := f.call("UpdateHostedDatabaseUser")
+			if err == nil && request.Buckets != nil {
+				f.users[clusterId][i].Access = *request.Buckets
+			}
+			return r, err
+		}
+	}
+	return f.notFound("UpdateHostedDatabaseUser", "user", userId)
+}
+
+func (f *fakeCapellaClient) DeleteHostedDatabaseUser(ctx context.Context, clusterId, userId string) (*http.Response, error) {
+	for i, user := range f.users[clusterId] {
+		if user.GetUserId() == userId {
+			r, err := f.call("DeleteHostedDatabaseUser")
+			if err == nil {
+				f.users[clusterId] = append(f.users[clusterId][:i:i], f.users[clusterId][i+1:]...)
+			}
+			return r, err
+		}
+	}
+	return f.notFound("DeleteHostedDatabaseUser", "user", userId)
+}
+
+// fakeHostedClusterServers is responsible for converting the servers of a hosted
+// cluster request into the servers the Capella API returns for the cluster.
+func fakeHostedClusterServers(servers []couchbasecapella.V3Servers) []couchbasecapella.V3ClusterServers {
+	result := make([]couchbasecapella.V3ClusterServers, len(servers))
+	for i, server := range servers {
+		services := make([]string, len(server.Services))
+		for j, service := range server.Services {
+			services[j] = string(service)
+		}
+		result[i] = couchbasecapella.V3ClusterServers{
+			Size:     server.Size,
+			Compute:  server.Compute,
+			Services: services,
+			Storage: couchbasecapella.V3ClusterStorage{
+				Type: string(server.Storage.Type),
+				IOPS: server.Storage.GetIOPS(),
+				Size: server.Storage.Size,
+			},
+		}
+	}
+	return result
+}
+
+// Test to see if reading a hosted cluster decodes the fields the generated model leaves out
+func TestClient_getHostedCluster(t *testing.T) {
+	server, client := newTestMockClient(t)
+	clusterId := server.AddHostedCluster(server.AddProject("project"), "cluster", "aws", "us-west-2", "10.0.16.0/20")
+
+	cluster, r, err := client.GetHostedCluster(context.Background(), clusterId)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if cluster.Name != "cluster" || cluster.Place.Region != "us-west-2" {
+		t.Fatalf("expected the cluster to be decoded, got %+v", cluster.V3Cluster)
+	}
+	if cluster.SingleAZ == nil || !*cluster.SingleAZ {
+		t.Fatalf("expected the cluster to be in a single availability zone, got %v", cluster.SingleAZ)
+	}
+	if cluster.SupportPackage == nil || *cluster.SupportPackage != (hostedSupportPackage{Timezone: "GMT", Type: "Basic"}) {
+		t.Fatalf("expected the Basic support package in GMT, got %+v", cluster.SupportPackage)
+	}
+	// The body stays readable once the cluster has been decoded
+	if body, err := ioutil.ReadAll(r.Body); err != nil || len(body) == 0 {
+		t.Fatalf("expected the body of the response, got %q: %v", body, err)
+	}
+}
+
+// Test to see if reading a vpc cluster decodes the servers the generated model leaves out
+func TestClient_getVpcCluster(t *testing.T) {
+	server, client := newTestMockClient(t)
+	clusterId := server.AddVpcCluster(server.AddProject("project"), server.AddCloud("aws", "us-east-1", "10.0.0.0/16"), "cluster")
+
+	cluster, _, err := client.GetVpcCluster(context.Background(), clusterId)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if cluster.Name != "cluster" || cluster.Version == nil {
+		t.Fatalf("expected the cluster to be decoded, got %+v", cluster.Cluster)
+	}
+	if len(cluster.Servers) != 1 || cluster.Servers[0].Aws == nil || cluster.Servers[0].Aws.InstanceSize != "m5.xlarge" {
+		t.Fatalf("expected the servers of the cluster, got %+v", cluster.Servers)
+	}
+}
+
+// Test to see if scaling a vpc cluster signs the request and surfaces the error of a rejected one
+func TestClient_updateVpcClusterServers(t *testing.T) {
+	server, client := newTestMockClient(t)
+	clusterId := server.AddVpcCluster(server.AddProject("project"), server.AddCloud("aws", "us-east-1", "10.0.0.0/16"), "cluster")
+	servers := []vpcServer{{
+		Size:     4,
+		Services: []couchbasecapella.CouchbaseServices{"data"},
+		Aws:      &couchbasecapella.ServerAws{InstanceSize: "m5.2xlarge", EbsSizeGib: 100},
+	}}
+	ctx := context.Background()
+
+	if r, err := client.UpdateVpcClusterServers(ctx, clusterId, servers); err != nil {
+		t.Fatalf("err: %v", manageErrors(err, r, "Update VPC Cluster"))
+	}
+	if status := server.ClusterStatus(clusterId); status != "deploying" {
+		t.Fatalf("expected the cluster to be scaling, got %q", status)
+	}
+	cluster, _, err := client.GetVpcCluster(ctx, clusterId)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(cluster.Servers) != 1 || cluster.Servers[0].Size != 4 || cluster.Servers[0].Aws.EbsSizeGib != 100 {
+		t.Fatalf("expected the servers to be scaled, got %+v", cluster.Servers)
+	}
+
+	// The cluster can't be scaled again until it is ready
+	r, err := client.UpdateVpcClusterServers(ctx, clusterId, servers)
+	if err == nil {
+		t.Fatal("expected an error while the cluster is scaling")
+	}
+	if diags := manageErrors(err, r, "Update VPC Cluster"); !strings.Contains(diags[0].Detail, "can't be scaled while deploying") {
+		t.Fatalf("expected the message of the error response, got %v", diags)
+	}
+}
@@ -0,0 +1,155 @@
+// Couchbase, Inc. licenses this to you under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at https://www.apache.org/licenses/LICENSE-2.0.
+
+// Unless required by applicable law or agreed to in writing, software distributed under the License
+// is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	couchbasecapella "github.com/couchbasecloud/couchbase-capella-api-go-client"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// DefaultAPIURL is the base URL of the Couchbase Capella public API.
+const DefaultAPIURL = "https://cloudapi.cloud.couchbase.com"
+
+// Config holds the settings of a provider block that are needed to
+// build a Couchbase Capella API client.
+type Config struct {
+	AccessKey string
+	SecretKey string
+	ProjectID string
+
+	// Profile and CredentialsFile select the API keys of the shared credentials
+	// file used when AccessKey or SecretKey aren't set.
+	Profile         string
+	CredentialsFile string
+
+	APIURL             string
+	RequestTimeout     time.Duration
+	ProxyURL           string
+	CAFile             string
+	InsecureSkipVerify bool
+
+	MaxRetries   int
+	RetryMinWait time.Duration
+	RetryMaxWait time.Duration
+}
+
+// Client is the provider-scoped Couchbase Capella API client. It implements
+// capellaClient, is returned by providerConfigure and handed to every CRUD
+// function as meta, so each provider alias talks to Capella with its own credentials.
+type Client struct {
+	*couchbasecapella.APIClient
+
+	accessKey string
+	secretKey string
+	// projectID is the project clusters default to when they don't set one.
+	projectID string
+}
+
+// NewClient is responsible for creating a Client from the provider configuration.
+func (c *Config) NewClient() (*Client, error) {
+	if err := c.loadCredentials(); err != nil {
+		return nil, err
+	}
+
+	httpClient, err := c.newHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
+	apiURL := c.APIURL
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+
+	configuration := couchbasecapella.NewConfiguration()
+	configuration.Servers = couchbasecapella.ServerConfigurations{
+		{
+			URL:         strings.TrimSuffix(apiURL, "/"),
+			Description: "Couchbase Capella API",
+		},
+	}
+	configuration.HTTPClient = httpClient
+
+	return &Client{
+		APIClient: couchbasecapella.NewAPIClient(configuration),
+		accessKey: c.AccessKey,
+		secretKey: c.SecretKey,
+		projectID: c.ProjectID,
+	}, nil
+}
+
+// newHTTPClient is responsible for building the http.Client used to reach the
+// Capella API, applying the timeout, proxy, TLS and retry settings of the provider.
+func (c *Config) newHTTPClient() (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if c.ProxyURL != "" {
+		proxyURL, err := url.Parse(c.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy_url %q: %v", c.ProxyURL, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: c.InsecureSkipVerify, // #nosec G402 -- opt-in for test and mock endpoints
+	}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read ca_file %q: %v", c.CAFile, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in ca_file %q", c.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{
+		Transport: newRetryTransport(&loggingTransport{next: transport}, c.MaxRetries, c.RetryMinWait, c.RetryMaxWait),
+		Timeout:   c.RequestTimeout,
+	}, nil
+}
+
+// getAuth returns a context carrying the API keys configured on the provider,
+// which the Capella API client uses to sign every request. The context is derived
+// from ctx so cancelling Terraform, or hitting a resource timeout, aborts the
+// in-flight requests made with it, and it carries the logger of the API subsystem.
+func (c *Client) getAuth(ctx context.Context) context.Context {
+	ctx = tflog.NewSubsystem(ctx, logSubsystem, tflog.WithLevelFromEnv(logSubsystemLevel, logSubsystem))
+	auth := context.WithValue(
+		ctx,
+		couchbasecapella.ContextAPIKeys,
+		map[string]couchbasecapella.APIKey{
+			"accessKey": {
+				Key: c.accessKey,
+			},
+			"secretKey": {
+				Key: c.secretKey,
+			},
+		},
+	)
+	return auth
+}
@@ -0,0 +1,183 @@
+// Couchbase, Inc. licenses this to you under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at https://www.apache.org/licenses/LICENSE-2.0.
+
+// Unless required by applicable law or agreed to in writing, software distributed under the License
+// is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+const testProjectResponse = `{"id":"c0ffee00-0000-4000-8000-000000000000","name":"project"}`
+
+// Test to see if requests are sent to the configured API URL
+func TestConfig_apiURL(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		if r.Header.Get("Authorization") == "" {
+			t.Errorf("request was not signed")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(testProjectResponse))
+	}))
+	defer server.Close()
+
+	config := Config{AccessKey: "access", SecretKey: "secret", APIURL: server.URL + "/"}
+	client, err := config.NewClient()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	auth := client.getAuth(context.Background())
+	if _, _, err := client.ProjectsApi.ProjectsShow(auth, "c0ffee00-0000-4000-8000-000000000000").Execute(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if path != "/v2/projects/c0ffee00-0000-4000-8000-000000000000" {
+		t.Fatalf("unexpected request path %s", path)
+	}
+}
+
+// Test to see if requests go through the configured proxy
+func TestConfig_proxyURL(t *testing.T) {
+	var host string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.URL.Host
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(testProjectResponse))
+	}))
+	defer proxy.Close()
+
+	config := Config{AccessKey: "access", SecretKey: "secret", APIURL: "http://capella.example.com", ProxyURL: proxy.URL}
+	client, err := config.NewClient()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	auth := client.getAuth(context.Background())
+	if _, _, err := client.ProjectsApi.ProjectsShow(auth, "c0ffee00-0000-4000-8000-000000000000").Execute(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if host != "capella.example.com" {
+		t.Fatalf("request did not go through the proxy, got host %q", host)
+	}
+}
+
+// Test to see if a custom CA bundle is trusted and an untrusted certificate is rejected
+func TestConfig_caFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(testProjectResponse))
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, certificate, 0600); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	untrusted, err := (&Config{AccessKey: "access", SecretKey: "secret", APIURL: server.URL}).NewClient()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	auth := untrusted.getAuth(context.Background())
+	if _, _, err := untrusted.ProjectsApi.ProjectsShow(auth, "c0ffee00-0000-4000-8000-000000000000").Execute(); err == nil {
+		t.Fatalf("expected the self-signed certificate to be rejected")
+	}
+
+	trusted, err := (&Config{AccessKey: "access", SecretKey: "secret", APIURL: server.URL, CAFile: caFile}).NewClient()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	auth = trusted.getAuth(context.Background())
+	if _, _, err := trusted.ProjectsApi.ProjectsShow(auth, "c0ffee00-0000-4000-8000-000000000000").Execute(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, err := (&Config{AccessKey: "access", SecretKey: "secret", CAFile: filepath.Join(t.TempDir(), "missing.pem")}).NewClient(); err == nil {
+		t.Fatalf("expected an error for a missing ca_file")
+	}
+}
+
+// Test to see if the connection settings fall back to their environment variables
+func TestProvider_configureEnvironment(t *testing.T) {
+	t.Setenv("CBC_API_URL", "https://capella.example.com")
+	t.Setenv("CBC_REQUEST_TIMEOUT", "5")
+	t.Setenv("CBC_INSECURE_SKIP_VERIFY", "true")
+	t.Setenv("CBC_MAX_RETRIES", "7")
+
+	provider := Provider()
+	diags := provider.Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"access_key": "access",
+		"secret_key": "secret",
+	}))
+	if diags.HasError() {
+		t.Fatalf("err: %v", diags)
+	}
+
+	client := provider.Meta().(*Client)
+	config := client.GetConfig()
+	if config.Servers[0].URL != "https://capella.example.com" {
+		t.Fatalf("unexpected api url %s", config.Servers[0].URL)
+	}
+	if config.HTTPClient.Timeout != 5*time.Second {
+		t.Fatalf("unexpected request timeout %s", config.HTTPClient.Timeout)
+	}
+	retry := config.HTTPClient.Transport.(*retryTransport)
+	if retry.maxRetries != 7 {
+		t.Fatalf("unexpected max retries %d", retry.maxRetries)
+	}
+	transport := retry.next.(*loggingTransport).next.(*http.Transport)
+	if !transport.TLSClientConfig.InsecureSkipVerify {
+		t.Fatalf("expected insecure_skip_verify to be read from the environment")
+	}
+}
+
+// Test to see if cancelling the Terraform context aborts an in-flight API call
+func TestClient_getAuthCancellation(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client, err := (&Config{AccessKey: "access", SecretKey: "secret", APIURL: server.URL}).NewClient()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	auth := client.getAuth(ctx)
+	want, _ := ctx.Deadline()
+	if got, ok := auth.Deadline(); !ok || !got.Equal(want) {
+		t.Fatalf("expected the auth context to keep the deadline of the Terraform context")
+	}
+
+	start := time.Now()
+	if _, _, err := client.ProjectsApi.ProjectsShow(auth, "c0ffee00-0000-4000-8000-000000000000").Execute(); err == nil {
+		t.Fatalf("expected the request to be cancelled")
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatalf("request was not cancelled with the Terraform context")
+	}
+}
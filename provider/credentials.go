@@ -0,0 +1,143 @@
+// Couchbase, Inc. licenses this to you under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at https://www.apache.org/licenses/LICENSE-2.0.
+
+// Unless required by applicable law or agreed to in writing, software distributed under the License
+// is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and limitations under the License.
+
+package provider
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// DefaultCredentialsFile is where the shared credentials file is looked up
+	// when no other location is configured.
+	DefaultCredentialsFile = "~/.couchbase/capella/credentials"
+	// DefaultProfile is the profile of the shared credentials file used when
+	// no other profile is configured.
+	DefaultProfile = "default"
+)
+
+// profileCredentials holds the API keys of a profile of the shared credentials file.
+type profileCredentials struct {
+	AccessKey string
+	SecretKey string
+}
+
+// loadCredentials is responsible for filling in the API keys that are set neither
+// in the provider block nor in the environment from a profile of the shared
+// credentials file. Keys that are already set take precedence over the profile.
+func (c *Config) loadCredentials() error {
+	if c.AccessKey != "" && c.SecretKey != "" {
+		return nil
+	}
+
+	profile := c.Profile
+	if profile == "" {
+		profile = DefaultProfile
+	}
+	path := c.CredentialsFile
+	if path == "" {
+		path = DefaultCredentialsFile
+	}
+	path, err := expandHome(path)
+	if err != nil {
+		return err
+	}
+
+	profiles, err := readCredentialsFile(path)
+	if err != nil {
+		// Without a file, only a profile that was asked for explicitly is an error.
+		if errors.Is(err, fs.ErrNotExist) && c.Profile == "" {
+			return fmt.Errorf(CredentialsMissing)
+		}
+		return fmt.Errorf("unable to read the shared credentials file %s: %v", path, err)
+	}
+
+	credentials, ok := profiles[profile]
+	if !ok {
+		if c.Profile == "" {
+			return fmt.Errorf(CredentialsMissing)
+		}
+		return fmt.Errorf(CredentialsProfileNotFound, profile, path)
+	}
+
+	if c.AccessKey == "" {
+		c.AccessKey = credentials.AccessKey
+	}
+	if c.SecretKey == "" {
+		c.SecretKey = credentials.SecretKey
+	}
+	if c.AccessKey == "" || c.SecretKey == "" {
+		return fmt.Errorf(CredentialsProfileIncomplete, profile, path)
+	}
+	return nil
+}
+
+// readCredentialsFile is responsible for parsing a shared credentials file. The
+// file is in INI format, with one section per profile:
+//
+//	[sandbox]
+//	access_key = xxxx
+//	secret_key = xxxx
+func readCredentialsFile(path string) (map[string]profileCredentials, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	profiles := make(map[string]profileCredentials)
+	profile := ""
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+			profile = strings.TrimSpace(text[1 : len(text)-1])
+			profiles[profile] = profileCredentials{}
+			continue
+		}
+
+		pair := strings.SplitN(text, "=", 2)
+		if len(pair) != 2 || profile == "" {
+			return nil, fmt.Errorf("line %d: expected a [profile] header or a key = value pair", line)
+		}
+		credentials := profiles[profile]
+		switch strings.TrimSpace(pair[0]) {
+		case "access_key":
+			credentials.AccessKey = strings.TrimSpace(pair[1])
+		case "secret_key":
+			credentials.SecretKey = strings.TrimSpace(pair[1])
+		}
+		profiles[profile] = credentials
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// expandHome replaces a leading ~ in path with the home directory of the user.
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to find the home directory to expand %s: %v", path, err)
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
@@ -0,0 +1,134 @@
+// Couchbase, Inc. licenses this to you under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at https://www.apache.org/licenses/LICENSE-2.0.
+
+// Unless required by applicable law or agreed to in writing, software distributed under the License
+// is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+const testCredentialsFile = `
+# Capella organizations
+[default]
+access_key = default-access
+secret_key = default-secret
+
+[sandbox]
+access_key = sandbox-access
+secret_key = sandbox-secret
+
+[partial]
+access_key = partial-access
+`
+
+func writeTestCredentialsFile(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "credentials")
+	if err := os.WriteFile(path, []byte(testCredentialsFile), 0600); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return path
+}
+
+// Test to see if the API keys are resolved with the precedence provider block > environment > profile
+func TestProvider_configureProfile(t *testing.T) {
+	path := writeTestCredentialsFile(t)
+
+	testCases := []struct {
+		name       string
+		env        map[string]string
+		config     map[string]interface{}
+		wantAccess string
+		wantSecret string
+	}{
+		{
+			name:       "default profile",
+			wantAccess: "default-access",
+			wantSecret: "default-secret",
+		},
+		{
+			name:       "profile argument",
+			config:     map[string]interface{}{"profile": "sandbox"},
+			wantAccess: "sandbox-access",
+			wantSecret: "sandbox-secret",
+		},
+		{
+			name:       "profile environment variable",
+			env:        map[string]string{"CBC_PROFILE": "sandbox"},
+			wantAccess: "sandbox-access",
+			wantSecret: "sandbox-secret",
+		},
+		{
+			name:       "environment over profile",
+			env:        map[string]string{"CBC_ACCESS_KEY": "env-access", "CBC_SECRET_KEY": "env-secret"},
+			config:     map[string]interface{}{"profile": "sandbox"},
+			wantAccess: "env-access",
+			wantSecret: "env-secret",
+		},
+		{
+			name:       "provider block over environment",
+			env:        map[string]string{"CBC_ACCESS_KEY": "env-access", "CBC_SECRET_KEY": "env-secret"},
+			config:     map[string]interface{}{"access_key": "block-access", "secret_key": "block-secret"},
+			wantAccess: "block-access",
+			wantSecret: "block-secret",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("CBC_ACCESS_KEY", "")
+			t.Setenv("CBC_SECRET_KEY", "")
+			t.Setenv("CBC_PROFILE", "")
+			t.Setenv("CBC_SHARED_CREDENTIALS_FILE", path)
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+
+			provider := Provider()
+			diags := provider.Configure(context.Background(), terraform.NewResourceConfigRaw(tc.config))
+			if diags.HasError() {
+				t.Fatalf("err: %v", diags)
+			}
+
+			client := provider.Meta().(*Client)
+			if client.accessKey != tc.wantAccess || client.secretKey != tc.wantSecret {
+				t.Fatalf("expected %s/%s, got %s/%s", tc.wantAccess, tc.wantSecret, client.accessKey, client.secretKey)
+			}
+		})
+	}
+}
+
+func TestConfig_loadCredentialsErrors(t *testing.T) {
+	path := writeTestCredentialsFile(t)
+	missing := filepath.Join(t.TempDir(), "credentials")
+
+	testCases := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{"no credentials at all", Config{CredentialsFile: missing}, "access_key and secret_key must be set"},
+		{"unknown profile", Config{CredentialsFile: path, Profile: "production"}, `profile "production" not found`},
+		{"profile without a file", Config{CredentialsFile: missing, Profile: "sandbox"}, "unable to read the shared credentials file"},
+		{"incomplete profile", Config{CredentialsFile: path, Profile: "partial"}, "must set both access_key and secret_key"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.loadCredentials()
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected an error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
@@ -1,18 +1,163 @@
 package provider
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	couchbasecapella "github.com/couchbasecloud/couchbase-capella-api-go-client"
+)
+
 // Error provides a custom type for creating named errors in various packages
 type Error string
 
 // Error implements the Error interface
 func (e Error) Error() string { return string(e) }
 
+// Kinds of Couchbase Capella API failures. An APIError unwraps to one of these,
+// so callers can test for them with errors.Is.
+const (
+	ErrBadRequest   Error = "the request was rejected by the Capella API"
+	ErrUnauthorized Error = "please verify the validity of your Access key and Secret key"
+	ErrForbidden    Error = "you don't have the required access to apply this function"
+	ErrNotFound     Error = "the resource doesn't exist in Capella"
+	ErrConflict     Error = "the resource already exists or is being modified by another operation"
+	ErrValidation   Error = "the Capella API rejected the configuration"
+	ErrThrottled    Error = "the Capella API is throttling requests, please try again later"
+	ErrServer       Error = "the Capella API failed to process the request, please try again later"
+	ErrUnexpected   Error = "the Capella API returned an unexpected response"
+)
+
+// APIError is a decoded Couchbase Capella API error response.
+type APIError struct {
+	Kind       Error
+	StatusCode int
+	// Code is the errorType or code reported by Capella.
+	Code    string
+	Message string
+	Hint    string
+	// Fields maps the API field names that failed validation to their message.
+	Fields    map[string]string
+	RequestID string
+}
+
+// Error implements the Error interface
+func (e *APIError) Error() string {
+	message := fmt.Sprintf("%d: %s", e.StatusCode, e.Kind)
+	if e.Message != "" {
+		message += ": " + e.Message
+	}
+	return message
+}
+
+// Unwrap returns the kind of the failure.
+func (e *APIError) Unwrap() error { return e.Kind }
+
+// apiErrorPayload covers the error payloads returned by the v2 and v3 Capella APIs.
+type apiErrorPayload struct {
+	Message   string                     `json:"message"`
+	ErrorType string                     `json:"errorType"`
+	Code      json.RawMessage            `json:"code"`
+	Hint      string                     `json:"hint"`
+	Field     string                     `json:"field"`
+	Errors    map[string]json.RawMessage `json:"errors"`
+}
+
+// newAPIError is responsible for decoding the error returned by the Capella API
+// client into an APIError. It returns nil when the request never got a response.
+func newAPIError(err error, r *http.Response) *APIError {
+	if r == nil {
+		return nil
+	}
+
+	apiErr := &APIError{
+		Kind:       errorKind(r.StatusCode),
+		StatusCode: r.StatusCode,
+		RequestID:  r.Header.Get("X-Request-Id"),
+	}
+
+	var body []byte
+	var openAPIErr couchbasecapella.GenericOpenAPIError
+	if errors.As(err, &openAPIErr) && len(openAPIErr.Body()) > 0 {
+		body = openAPIErr.Body()
+	} else if r.Body != nil {
+		body, _ = io.ReadAll(r.Body)
+	}
+
+	var payload apiErrorPayload
+	if json.Unmarshal(body, &payload) != nil {
+		apiErr.Message = strings.TrimSpace(string(body))
+		return apiErr
+	}
+
+	apiErr.Message = payload.Message
+	apiErr.Hint = payload.Hint
+	apiErr.Code = payload.ErrorType
+	if apiErr.Code == "" && len(payload.Code) > 0 {
+		apiErr.Code = strings.Trim(string(payload.Code), `"`)
+	}
+
+	apiErr.Fields = make(map[string]string)
+	if payload.Field != "" {
+		apiErr.Fields[payload.Field] = payload.Message
+	}
+	for field, raw := range payload.Errors {
+		var message string
+		var messages []string
+		if json.Unmarshal(raw, &message) == nil {
+			apiErr.Fields[field] = message
+		} else if json.Unmarshal(raw, &messages) == nil {
+			apiErr.Fields[field] = strings.Join(messages, "; ")
+		}
+	}
+
+	return apiErr
+}
+
+// errorKind classifies an HTTP status code returned by the Capella API.
+func errorKind(statusCode int) Error {
+	switch {
+	case statusCode == http.StatusBadRequest:
+		return ErrBadRequest
+	case statusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case statusCode == http.StatusForbidden:
+		return ErrForbidden
+	case statusCode == http.StatusNotFound:
+		return ErrNotFound
+	case statusCode == http.StatusConflict:
+		return ErrConflict
+	case statusCode == http.StatusUnprocessableEntity:
+		return ErrValidation
+	case statusCode == http.StatusTooManyRequests:
+		return ErrThrottled
+	case statusCode >= 500:
+		return ErrServer
+	default:
+		return ErrUnexpected
+	}
+}
+
 const (
-	BucketHostedNotSupported        string = "this current release of the terraform provider doesn't support managing buckets in hosted clusters, please log in to the Capella UI where you can update your cluster"
 	BucketInvalidName               string = "use letters, numbers, periods (.) or dashes (-). Bucket names cannot exceed 100 characters and must begin with a letter or a number"
 	BucketInvalidMemoryQuota        string = "expected a value greater than 100 MiB, got %v MiB"
 	BucketInvalidConflictResolution string = "expected a valid value for conflict resolution {lww, seqno}, got %s"
+	BucketInvalidReplicas           string = "expected a number of replicas between 0 and 3, got %v"
+	BucketInvalidDurabilityLevel    string = "expected a valid value for durability level {none, majority, majorityAndPersistActive, persistToMajority}, got %s"
+	BucketInvalidEvictionPolicy     string = "expected a valid value for eviction policy {valueOnly, fullEviction, noEviction, nruEviction}, got %s"
+	BucketInvalidMaxTTL             string = "expected a max TTL between 0 and 2147483647 seconds, got %v"
+	BucketInvalidStorageBackend     string = "expected a valid value for storage backend {couchstore, magma}, got %s"
+	BucketInvalidType               string = "expected a valid value for bucket type {couchbase, ephemeral}, got %s"
+	BucketInvalidMagmaMemoryQuota   string = "the magma storage backend needs a memory quota of at least %d MiB, got %d MiB"
+
+	BucketEvictionPolicyNotSupported  string = "%s buckets support the eviction policies {%s}, got %s"
+	BucketStorageBackendNotSupported  string = "ephemeral buckets keep their data in memory and can't set a storage backend"
+	BucketDurabilityLevelNotSupported string = "ephemeral buckets support the durability levels {none, majority}, got %s"
+	BucketVpcSettingNotUpdatable      string = "%s of a bucket in a vpc cluster can't be updated in place, only memory_quota can"
 
-	DatabaseUserHostedNotSupported     string = "this current release of the terraform provider doesn't support managing database users in hosted clusters, please log in to the Capella UI where you can update your cluster"
 	DatabaseUserInvalidPassword        string = "password must contain 8+ characters, 1+ lowercase, 1+ uppercase, 1+ symbols, 1+ numbers"
 	DatabaseUserInvalidBucketAccess    string = "expected a valid value for bucket access {data_reader, data_writer}, got %s"
 	DatabaseUserInvalidAllBucketAccess string = "expected a valid value for all bucket access {data_reader, data_writer}, got %s"
@@ -24,11 +169,17 @@ const (
 	HostedClusterInvalidSupportPackageType     string = "expected a valid value for support package type {Basic, DeveloperPro, Enterprise}, got %s"
 	HostedClusterInvalidCompute                string = "expected a valid value for compute instance, got %s"
 	HostedClusterInvalidIOPS                   string = "if storage type is GP3, iops should be a value between 3000 and 16000. If storage type is IO2, iops should be a value between 1000 and 64000"
+	HostedClusterBasicSupportPackageMultiAZ    string = "the Basic support package only supports clusters in a single availability zone, set place.single_az to true or choose another support package"
+	HostedClusterInvalidProviderRegion         string = "place.hosted.region: expected a valid region for %s, got %s"
+	HostedClusterInvalidProviderCompute        string = "servers.compute of the %s server group: expected a valid compute instance for %s, got %s"
+	HostedClusterInvalidProviderStorageType    string = "servers.storage.storage_type of the %s server group: expected a valid storage type for %s {%s}, got %s"
+	HostedClusterInvalidStorageTypeIOPS        string = "servers.storage.iops of the %s server group: expected a value between %d and %d for %s storage, got %d"
+	HostedClusterGCPIOPS                       string = "servers.storage.iops of the %s server group: iops can't be set for gcp storage"
 
-	VpcClusterUpdateNotSupported         string = "This current release of the terraform provider doesn't support updating vpc clusters, please log in to the Capella UI where you can update your cluster"
 	VpcClusterInvalidAwsInstance         string = "expected a valid value Aws instance, got %s"
 	VpcClusterInvalidAzureInstance       string = "expected a valid value Azure instance, got %s"
 	VpcClusterInvalidAzureVolumeSize     string = "expected a valid value for Azure size, got %s"
+	VpcClusterInvalidGcpInstance         string = "expected a valid value Gcp instance, got %s"
 	VpcClusterServerDoesNotMatchProvider string = "cluster's server should be the same as the cloud provider"
 
 	ClusterInvalidName             string = "cluster name can include letters, numbers, spaces, periods (.), dashes (-), and underscores (_). Cluster name should be between 2 and 128 characters and must begin with a letter or a number"
@@ -37,6 +188,19 @@ const (
 	ClusterInvalidStorageType      string = "expected a valid value for storage type {GP3, IO2}, got %s"
 	ClusterProblemAccessing        string = "a problem occurred while accessing the cluster"
 	ClusterInvalidStorageSize      string = "expected a value between 50 and 16000, got %v"
+	ClusterMissingProjectID        string = "project_id must be set either on the resource or on the provider"
+	ClusterMissingDataService      string = "servers: at least one server group must include the data service"
+	ClusterDuplicateService        string = "servers.services of the %s server group: the %s service is already in the %s server group, each service can only be in one server group"
+	ClusterInvalidServerGroupCount string = "servers: a %s can have at most %d server groups, got %d"
+
+	CredentialsMissing           string = "access_key and secret_key must be set in the provider block, with the CBC_ACCESS_KEY and CBC_SECRET_KEY environment variables or in a profile of the shared credentials file"
+	CredentialsProfileNotFound   string = "profile %q not found in the shared credentials file %s"
+	CredentialsProfileIncomplete string = "profile %q of the shared credentials file %s must set both access_key and secret_key"
 
 	ProjectDeleteClustersStillAssociated string = "Project cannot be deleted whilst there are still clusters associated with the project"
 )
+
+const (
+	RetryBodyNotRewindable Error = "the request body can't be rewound to retry the request"
+	ErrEmptyResponse       Error = "the Capella API returned an empty response"
+)
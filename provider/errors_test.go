@@ -0,0 +1,168 @@
+// Couchbase, Inc. licenses this to you under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at https://www.apache.org/licenses/LICENSE-2.0.
+
+// Unless required by applicable law or agreed to in writing, software distributed under the License
+// is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+)
+
+// Test to see if the error payloads of the v2 and v3 APIs are decoded
+func TestNewAPIError(t *testing.T) {
+	testCases := []struct {
+		name        string
+		status      int
+		body        string
+		wantKind    Error
+		wantCode    string
+		wantMessage string
+		wantFields  map[string]string
+	}{
+		{
+			name:        "v2 payload",
+			status:      http.StatusForbidden,
+			body:        `{"errorType":"Forbidden","message":"access denied"}`,
+			wantKind:    ErrForbidden,
+			wantCode:    "Forbidden",
+			wantMessage: "access denied",
+		},
+		{
+			name:        "v3 payload with a field",
+			status:      http.StatusUnprocessableEntity,
+			body:        `{"code":4001,"hint":"use a private range","message":"invalid CIDR","field":"place.CIDR"}`,
+			wantKind:    ErrValidation,
+			wantCode:    "4001",
+			wantMessage: "invalid CIDR",
+			wantFields:  map[string]string{"place.CIDR": "invalid CIDR"},
+		},
+		{
+			name:        "field errors",
+			status:      http.StatusBadRequest,
+			body:        `{"message":"validation failed","errors":{"memoryQuota":"too small","name":["too long","invalid"]}}`,
+			wantKind:    ErrBadRequest,
+			wantMessage: "validation failed",
+			wantFields:  map[string]string{"memoryQuota": "too small", "name": "too long; invalid"},
+		},
+		{
+			name:        "plain text body",
+			status:      http.StatusBadGateway,
+			body:        "upstream unavailable\n",
+			wantKind:    ErrServer,
+			wantMessage: "upstream unavailable",
+		},
+		{
+			name:     "throttled",
+			status:   http.StatusTooManyRequests,
+			wantKind: ErrThrottled,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &http.Response{
+				StatusCode: tc.status,
+				Header:     http.Header{"X-Request-Id": []string{"req-1"}},
+				Body:       io.NopCloser(strings.NewReader(tc.body)),
+			}
+			apiErr := newAPIError(errors.New(http.StatusText(tc.status)), r)
+
+			if !errors.Is(apiErr, tc.wantKind) {
+				t.Fatalf("expected kind %q, got %q", tc.wantKind, apiErr.Kind)
+			}
+			if apiErr.Code != tc.wantCode || apiErr.Message != tc.wantMessage || apiErr.RequestID != "req-1" {
+				t.Fatalf("unexpected error %+v", apiErr)
+			}
+			for field, message := range tc.wantFields {
+				if apiErr.Fields[field] != message {
+					t.Fatalf("expected %q for field %s, got %q", message, field, apiErr.Fields[field])
+				}
+			}
+		})
+	}
+}
+
+// Test to see if errors returned by the generated client keep the API payload
+func TestManageErrors_client(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"errorType":"NotFound","message":"project not found"}`))
+	}))
+	defer server.Close()
+
+	client, err := (&Config{AccessKey: "access", SecretKey: "secret", APIURL: server.URL}).NewClient()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	auth := client.getAuth(context.Background())
+	_, r, err := client.ProjectsApi.ProjectsShow(auth, "c0ffee00-0000-4000-8000-000000000000").Execute()
+
+	diags := manageErrors(err, r, "Read Project")
+	if len(diags) != 1 {
+		t.Fatalf("expected one diagnostic, got %v", diags)
+	}
+	if diags[0].Summary != "Read Project: "+string(ErrNotFound) {
+		t.Fatalf("unexpected summary %q", diags[0].Summary)
+	}
+	if !strings.Contains(diags[0].Detail, "project not found") || !strings.Contains(diags[0].Detail, "NotFound") {
+		t.Fatalf("unexpected detail %q", diags[0].Detail)
+	}
+}
+
+// Test to see if validation failures are attached to the matching resource argument
+func TestManageErrors_attributePath(t *testing.T) {
+	r := &http.Response{
+		StatusCode: http.StatusUnprocessableEntity,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(`{"message":"invalid","errors":{"servers[0].storage.IOPS":"out of range","memoryQuota":"too small"}}`)),
+	}
+
+	diags := manageErrors(errors.New("422 Unprocessable Entity"), r, "Create Hosted Cluster")
+	if len(diags) != 2 {
+		t.Fatalf("expected two diagnostics, got %v", diags)
+	}
+	if !diags[0].AttributePath.Equals(cty.GetAttrPath("memory_quota")) {
+		t.Fatalf("unexpected attribute path %#v", diags[0].AttributePath)
+	}
+	if !diags[1].AttributePath.Equals(cty.GetAttrPath("servers")) {
+		t.Fatalf("unexpected attribute path %#v", diags[1].AttributePath)
+	}
+}
+
+func TestManageErrors_noResponse(t *testing.T) {
+	if diags := manageErrors(nil, nil, "Create Project"); diags != nil {
+		t.Fatalf("expected no diagnostics, got %v", diags)
+	}
+	diags := manageErrors(errors.New("connection refused"), nil, "Create Project")
+	if len(diags) != 1 || diags[0].Summary != "Create Project: connection refused" {
+		t.Fatalf("unexpected diagnostics %v", diags)
+	}
+}
+
+func TestToSnakeCase(t *testing.T) {
+	for name, want := range map[string]string{
+		"memoryQuota":      "memory_quota",
+		"singleAZ":         "single_az",
+		"CIDR":             "cidr",
+		"IOPSValue":        "iops_value",
+		"allBucketsAccess": "all_buckets_access",
+		"name":             "name",
+	} {
+		if got := toSnakeCase(name); got != want {
+			t.Fatalf("expected %s for %s, got %s", want, name, got)
+		}
+	}
+}
@@ -0,0 +1,563 @@
+// Couchbase, Inc. licenses this to you under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at https://www.apache.org/licenses/LICENSE-2.0.
+
+// Unless required by applicable law or agreed to in writing, software distributed under the License
+// is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and limitations under the License.
+
+package capellamock
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	minMemoryQuota      = 100
+	minMagmaMemoryQuota = 1024
+	maxReplicas         = 3
+)
+
+// evictionPolicies are the eviction policies of each type of bucket, the first
+// one being the default.
+var evictionPolicies = map[string][]string{
+	"couchbase": {"valueOnly", "fullEviction"},
+	"ephemeral": {"noEviction", "nruEviction"},
+}
+
+type bucket struct {
+	ID                 string `json:"id"`
+	Name               string `json:"name"`
+	MemoryQuota        int    `json:"memoryQuota"`
+	Replicas           int    `json:"replicas"`
+	ConflictResolution string `json:"conflictResolution"`
+	DurabilityLevel    string `json:"durabilityLevel"`
+	EvictionPolicy     string `json:"evictionPolicy"`
+	MaxTTL             int    `json:"maxTTL"`
+	StorageBackend     string `json:"storageBackend,omitempty"`
+	BucketType         string `json:"bucketType"`
+	FlushEnabled       bool   `json:"flushEnabled"`
+	Status             string `json:"status"`
+
+	// visibleAt is when the bucket appears in the list of buckets of its cluster.
+	visibleAt time.Time
+	// update holds the new settings of the bucket until they appear in the
+	// list of buckets of its cluster, at updateAt.
+	update   *bucket
+	updateAt time.Time
+}
+
+// bucketSpec is the request body of the v2 bucket endpoints.
+type bucketSpec struct {
+	Name               string  `json:"name"`
+	MemoryQuota        int     `json:"memoryQuota"`
+	Replicas           *int    `json:"replicas,omitempty"`
+	ConflictResolution *string `json:"conflictResolution,omitempty"`
+	DurabilityLevel    *string `json:"durabilityLevel,omitempty"`
+	EvictionPolicy     *string `json:"evictionPolicy,omitempty"`
+	MaxTTL             *int    `json:"maxTTL,omitempty"`
+	StorageBackend     *string `json:"storageBackend,omitempty"`
+	BucketType         *string `json:"bucketType,omitempty"`
+	FlushEnabled       *bool   `json:"flushEnabled,omitempty"`
+}
+
+// hostedBucket is the request and response body of the v3 bucket endpoints.
+type hostedBucket struct {
+	ID                       string  `json:"id,omitempty"`
+	Name                     string  `json:"name"`
+	MemoryAllocationInMb     int     `json:"memoryAllocationInMb"`
+	Replicas                 *int    `json:"replicas,omitempty"`
+	BucketConflictResolution string  `json:"bucketConflictResolution,omitempty"`
+	DurabilityLevel          *string `json:"durabilityLevel,omitempty"`
+	EvictionPolicy           *string `json:"evictionPolicy,omitempty"`
+	TimeToLive               *int    `json:"timeToLive,omitempty"`
+	StorageBackend           *string `json:"storageBackend,omitempty"`
+	Type                     *string `json:"type,omitempty"`
+	Flush                    *bool   `json:"flush,omitempty"`
+	Status                   string  `json:"status,omitempty"`
+}
+
+// bucketSettings are the optional settings of a bucket in a request body of the
+// v2 or v3 bucket endpoints, nil when the request doesn't set them.
+type bucketSettings struct {
+	Replicas           *int
+	ConflictResolution *string
+	DurabilityLevel    *string
+	EvictionPolicy     *string
+	MaxTTL             *int
+	StorageBackend     *string
+	BucketType         *string
+	FlushEnabled       *bool
+}
+
+func (b bucketSpec) settings() bucketSettings {
+	return bucketSettings{
+		Replicas:           b.Replicas,
+		ConflictResolution: b.ConflictResolution,
+		DurabilityLevel:    b.DurabilityLevel,
+		EvictionPolicy:     b.EvictionPolicy,
+		MaxTTL:             b.MaxTTL,
+		StorageBackend:     b.StorageBackend,
+		BucketType:         b.BucketType,
+		FlushEnabled:       b.FlushEnabled,
+	}
+}
+
+func (b hostedBucket) settings() bucketSettings {
+	settings := bucketSettings{
+		Replicas:        b.Replicas,
+		DurabilityLevel: b.DurabilityLevel,
+		EvictionPolicy:  b.EvictionPolicy,
+		MaxTTL:          b.TimeToLive,
+		StorageBackend:  b.StorageBackend,
+		BucketType:      b.Type,
+		FlushEnabled:    b.Flush,
+	}
+	if b.BucketConflictResolution != "" {
+		settings.ConflictResolution = &b.BucketConflictResolution
+	}
+	return settings
+}
+
+// spec returns the bucket as a v2 bucket request body.
+func (b *bucket) spec() bucketSpec {
+	return bucketSpec{
+		Name:               b.Name,
+		MemoryQuota:        b.MemoryQuota,
+		Replicas:           &b.Replicas,
+		ConflictResolution: &b.ConflictResolution,
+		DurabilityLevel:    &b.DurabilityLevel,
+		EvictionPolicy:     &b.EvictionPolicy,
+		MaxTTL:             &b.MaxTTL,
+		StorageBackend:     &b.StorageBackend,
+		BucketType:         &b.BucketType,
+		FlushEnabled:       &b.FlushEnabled,
+	}
+}
+
+// hosted returns the bucket as a v3 bucket response body.
+func (b *bucket) hosted() hostedBucket {
+	return hostedBucket{
+		ID:                       b.ID,
+		Name:                     b.Name,
+		MemoryAllocationInMb:     b.MemoryQuota,
+		Replicas:                 &b.Replicas,
+		BucketConflictResolution: b.ConflictResolution,
+		DurabilityLevel:          &b.DurabilityLevel,
+		EvictionPolicy:           &b.EvictionPolicy,
+		TimeToLive:               &b.MaxTTL,
+		StorageBackend:           &b.StorageBackend,
+		Type:                     &b.BucketType,
+		Flush:                    &b.FlushEnabled,
+		Status:                   b.Status,
+	}
+}
+
+// configure applies the settings of a request creating the bucket. An ephemeral
+// bucket has no storage backend and its own default eviction policy.
+func (b *bucket) configure(settings bucketSettings) {
+	if settings.BucketType != nil {
+		b.BucketType = *settings.BucketType
+	}
+	if policies, ok := evictionPolicies[b.BucketType]; ok {
+		b.EvictionPolicy = policies[0]
+	}
+	if b.BucketType == "ephemeral" {
+		b.StorageBackend = ""
+	}
+	if settings.ConflictResolution != nil {
+		b.ConflictResolution = *settings.ConflictResolution
+	}
+	if settings.EvictionPolicy != nil {
+		b.EvictionPolicy = *settings.EvictionPolicy
+	}
+	if settings.StorageBackend != nil {
+		b.StorageBackend = *settings.StorageBackend
+	}
+	b.change(settings)
+}
+
+// change applies the settings of a request that can be changed once the bucket exists.
+func (b *bucket) change(settings bucketSettings) {
+	if settings.Replicas != nil {
+		b.Replicas = *settings.Replicas
+	}
+	if settings.DurabilityLevel != nil {
+		b.DurabilityLevel = *settings.DurabilityLevel
+	}
+	if settings.MaxTTL != nil {
+		b.MaxTTL = *settings.MaxTTL
+	}
+	if settings.FlushEnabled != nil {
+		b.FlushEnabled = *settings.FlushEnabled
+	}
+}
+
+// immutable returns the name of the first setting of a request that would change
+// a setting of the bucket that can't be changed once it exists, if any.
+func (b *bucket) immutable(settings bucketSettings) string {
+	for _, setting := range []struct {
+		name    string
+		value   *string
+		current string
+	}{
+		{"conflictResolution", settings.ConflictResolution, b.ConflictResolution},
+		{"evictionPolicy", settings.EvictionPolicy, b.EvictionPolicy},
+		{"storageBackend", settings.StorageBackend, b.StorageBackend},
+		{"bucketType", settings.BucketType, b.BucketType},
+	} {
+		if setting.value != nil && *setting.value != "" && *setting.value != setting.current {
+			return setting.name
+		}
+	}
+	return ""
+}
+
+// AddBucket adds a bucket with the default settings to a cluster of the server.
+func (s *Server) AddBucket(clusterID, name string, memoryQuota int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.clusters[clusterID]
+	if !ok {
+		panic(fmt.Sprintf("capellamock: unknown cluster %s", clusterID))
+	}
+	c.buckets[name] = newBucket(name, memoryQuota)
+}
+
+// newBucket returns a healthy bucket. Capella derives the ID of a bucket from its name.
+func newBucket(name string, memoryQuota int) *bucket {
+	return &bucket{
+		ID:                 base64.RawURLEncoding.EncodeToString([]byte(name)),
+		Name:               name,
+		MemoryQuota:        memoryQuota,
+		Replicas:           1,
+		ConflictResolution: "seqno",
+		DurabilityLevel:    "none",
+		EvictionPolicy:     "valueOnly",
+		StorageBackend:     "couchstore",
+		BucketType:         "couchbase",
+		Status:             "healthy",
+	}
+}
+
+// validateBucket returns an error message if the settings of the bucket are invalid.
+func validateBucket(b *bucket) string {
+	switch {
+	case strings.TrimSpace(b.Name) == "":
+		return "name must be set"
+	case b.MemoryQuota < minMemoryQuota:
+		return fmt.Sprintf("memoryQuota must be at least %d MB", minMemoryQuota)
+	case b.Replicas < 0 || b.Replicas > maxReplicas:
+		return fmt.Sprintf("replicas must be between 0 and %d", maxReplicas)
+	case b.ConflictResolution != "seqno" && b.ConflictResolution != "lww":
+		return "conflictResolution must be seqno or lww"
+	case b.BucketType != "couchbase" && b.BucketType != "ephemeral":
+		return "bucketType must be couchbase or ephemeral"
+	case !contains(evictionPolicies[b.BucketType], b.EvictionPolicy):
+		return fmt.Sprintf("evictionPolicy of a %s bucket must be one of %s", b.BucketType, strings.Join(evictionPolicies[b.BucketType], ", "))
+	case b.MaxTTL < 0:
+		return "maxTTL can't be negative"
+	}
+	if b.BucketType == "ephemeral" {
+		switch {
+		case b.StorageBackend != "":
+			return "storageBackend can't be set for an ephemeral bucket"
+		case b.DurabilityLevel != "none" && b.DurabilityLevel != "majority":
+			return "durabilityLevel of an ephemeral bucket must be none or majority"
+		}
+		return ""
+	}
+	switch {
+	case b.StorageBackend != "couchstore" && b.StorageBackend != "magma":
+		return "storageBackend must be couchstore or magma"
+	case b.StorageBackend == "magma" && b.MemoryQuota < minMagmaMemoryQuota:
+		return fmt.Sprintf("memoryQuota of a magma bucket must be at least %d MB", minMagmaMemoryQuota)
+	case !contains([]string{"none", "majority", "majorityAndPersistActive", "persistToMajority"}, b.DurabilityLevel):
+		return "durabilityLevel must be none, majority, majorityAndPersistActive or persistToMajority"
+	}
+	return ""
+}
+
+// readyVpcCluster returns the vpc cluster of the request path, writing an error
+// response if there isn't one or if it can't be changed yet.
+func (s *Server) readyVpcCluster(w http.ResponseWriter, r *http.Request, id string) (*cluster, bool) {
+	c, ok := s.vpcCluster(w, id)
+	if !ok {
+		return nil, false
+	}
+	if r.Method != http.MethodGet && !c.ready() {
+		writeError(w, http.StatusUnprocessableEntity, "UnprocessableEntity", fmt.Sprintf("cluster %s is %s", c.ID, c.Status))
+		return nil, false
+	}
+	return c, true
+}
+
+// bucketByID returns the bucket of a cluster with the given ID.
+func (c *cluster) bucketByID(id string) (*bucket, bool) {
+	for _, b := range c.buckets {
+		if b.ID == id {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+// readyHostedCluster returns the hosted cluster of the request path, writing an
+// error response if there isn't one or if it can't be changed yet.
+func (s *Server) readyHostedCluster(w http.ResponseWriter, r *http.Request, id string) (*cluster, bool) {
+	c, ok := s.hostedCluster(w, id)
+	if !ok {
+		return nil, false
+	}
+	if r.Method != http.MethodGet && !c.ready() {
+		writeV3Error(w, http.StatusUnprocessableEntity, "", fmt.Sprintf("cluster %s is %s", c.ID, c.Status))
+		return nil, false
+	}
+	return c, true
+}
+
+// visibleBuckets returns the buckets of a cluster that appear in its list of buckets, by name.
+func (s *Server) visibleBuckets(c *cluster) []*bucket {
+	now := s.now()
+	buckets := make([]*bucket, 0, len(c.buckets))
+	for _, b := range c.buckets {
+		if b.update != nil && !now.Before(b.updateAt) {
+			*b = *b.update
+		}
+		if !now.Before(b.visibleAt) {
+			buckets = append(buckets, b)
+		}
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Name < buckets[j].Name })
+	return buckets
+}
+
+// updateBucket validates the new settings of a bucket, which appear in the list of
+// buckets of its cluster after the list delay, returning the status and message of
+// the error response if they are invalid.
+func (s *Server) updateBucket(b *bucket, updated bucket) (int, string) {
+	if message := validateBucket(&updated); message != "" {
+		return http.StatusUnprocessableEntity, message
+	}
+	updated.update = nil
+	b.update, b.updateAt = &updated, s.now().Add(s.ListDelay)
+	return 0, ""
+}
+
+// addBucket adds a new bucket to a cluster, returning the status and message of
+// the error response if it can't be added.
+func (s *Server) addBucket(c *cluster, b *bucket) (int, string) {
+	if _, ok := c.buckets[b.Name]; ok {
+		return http.StatusUnprocessableEntity, fmt.Sprintf("bucket %s already exists", b.Name)
+	}
+	if message := validateBucket(b); message != "" {
+		return http.StatusUnprocessableEntity, message
+	}
+	b.visibleAt = s.now().Add(s.ListDelay)
+	c.buckets[b.Name] = b
+	return 0, ""
+}
+
+func (s *Server) listBuckets(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.readyVpcCluster(w, r, params[0])
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, s.visibleBuckets(c))
+}
+
+func (s *Server) createBucket(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.readyVpcCluster(w, r, params[0])
+	if !ok {
+		return
+	}
+	var request bucketSpec
+	if !decodeBody(w, r, &request) {
+		return
+	}
+
+	b := newBucket(request.Name, request.MemoryQuota)
+	b.configure(request.settings())
+	if status, message := s.addBucket(c, b); status != 0 {
+		writeError(w, status, errorType(status), message)
+		return
+	}
+	writeJSON(w, http.StatusCreated, b.spec())
+}
+
+func (s *Server) replaceBucket(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.readyVpcCluster(w, r, params[0])
+	if !ok {
+		return
+	}
+	var request bucketSpec
+	if !decodeBody(w, r, &request) {
+		return
+	}
+
+	b, ok := c.buckets[request.Name]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NotFound", fmt.Sprintf("bucket %s not found", request.Name))
+		return
+	}
+	if setting := b.immutable(request.settings()); setting != "" {
+		writeError(w, http.StatusUnprocessableEntity, "UnprocessableEntity", setting+" can't be changed")
+		return
+	}
+	updated := *b
+	updated.MemoryQuota = request.MemoryQuota
+	updated.change(request.settings())
+	if message := validateBucket(&updated); message != "" {
+		writeError(w, http.StatusUnprocessableEntity, "UnprocessableEntity", message)
+		return
+	}
+
+	*b = updated
+	writeJSON(w, http.StatusOK, b.spec())
+}
+
+// updateSingleBucket only updates the memory quota of a bucket, like the v2
+// endpoint it implements. Any other setting in the request is ignored.
+func (s *Server) updateSingleBucket(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.readyVpcCluster(w, r, params[0])
+	if !ok {
+		return
+	}
+	var request struct {
+		MemoryQuota int `json:"memoryQuota"`
+	}
+	if !decodeBody(w, r, &request) {
+		return
+	}
+
+	b, ok := c.bucketByID(params[1])
+	if !ok {
+		writeError(w, http.StatusNotFound, "NotFound", fmt.Sprintf("bucket %s not found", params[1]))
+		return
+	}
+	updated := *b
+	updated.MemoryQuota = request.MemoryQuota
+	if status, message := s.updateBucket(b, updated); status != 0 {
+		writeError(w, status, errorType(status), message)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteBucketByName(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.readyVpcCluster(w, r, params[0])
+	if !ok {
+		return
+	}
+	var request struct {
+		Name string `json:"name"`
+	}
+	if !decodeBody(w, r, &request) {
+		return
+	}
+
+	if _, ok := c.buckets[request.Name]; !ok {
+		writeError(w, http.StatusNotFound, "NotFound", fmt.Sprintf("bucket %s not found", request.Name))
+		return
+	}
+	delete(c.buckets, request.Name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteBucket(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.readyVpcCluster(w, r, params[0])
+	if !ok {
+		return
+	}
+
+	b, ok := c.bucketByID(params[1])
+	if !ok {
+		writeError(w, http.StatusNotFound, "NotFound", fmt.Sprintf("bucket %s not found", params[1]))
+		return
+	}
+	delete(c.buckets, b.Name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listHostedBuckets(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.readyHostedCluster(w, r, params[0])
+	if !ok {
+		return
+	}
+	buckets := s.visibleBuckets(c)
+	start, end, page := paginate(r, len(buckets))
+	data := make([]hostedBucket, 0, end-start)
+	for _, b := range buckets[start:end] {
+		data = append(data, b.hosted())
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"cursor": page, "data": data})
+}
+
+func (s *Server) createHostedBucket(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.readyHostedCluster(w, r, params[0])
+	if !ok {
+		return
+	}
+	var request hostedBucket
+	if !decodeBody(w, r, &request) {
+		return
+	}
+
+	b := newBucket(request.Name, request.MemoryAllocationInMb)
+	b.configure(request.settings())
+	if status, message := s.addBucket(c, b); status != 0 {
+		writeV3Error(w, status, "", message)
+		return
+	}
+	writeJSON(w, http.StatusCreated, b.hosted())
+}
+
+func (s *Server) updateHostedBucket(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.readyHostedCluster(w, r, params[0])
+	if !ok {
+		return
+	}
+	var request hostedBucket
+	if !decodeBody(w, r, &request) {
+		return
+	}
+
+	b, ok := c.bucketByID(params[1])
+	if !ok {
+		writeV3Error(w, http.StatusNotFound, "", fmt.Sprintf("bucket %s not found", params[1]))
+		return
+	}
+	if setting := b.immutable(request.settings()); setting != "" {
+		writeV3Error(w, http.StatusUnprocessableEntity, setting, setting+" can't be changed")
+		return
+	}
+	updated := *b
+	updated.MemoryQuota = request.MemoryAllocationInMb
+	updated.change(request.settings())
+	if status, message := s.updateBucket(b, updated); status != 0 {
+		writeV3Error(w, status, "", message)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteHostedBucket(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.readyHostedCluster(w, r, params[0])
+	if !ok {
+		return
+	}
+
+	b, ok := c.bucketByID(params[1])
+	if !ok {
+		writeV3Error(w, http.StatusNotFound, "", fmt.Sprintf("bucket %s not found", params[1]))
+		return
+	}
+	delete(c.buckets, b.Name)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,118 @@
+// Couchbase, Inc. licenses this to you under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at https://www.apache.org/licenses/LICENSE-2.0.
+
+// Unless required by applicable law or agreed to in writing, software distributed under the License
+// is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and limitations under the License.
+
+package capellamock
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+type cloud struct {
+	ID        string
+	Name      string
+	Provider  string
+	Region    string
+	CIDR      string
+	NetworkID string
+	CreatedAt time.Time
+}
+
+// summary returns the representation of the cloud in the list of clouds.
+func (c *cloud) summary() map[string]interface{} {
+	return map[string]interface{}{
+		"id":                 c.ID,
+		"name":               c.Name,
+		"status":             "ready",
+		"provider":           c.Provider,
+		"region":             c.Region,
+		"virtualNetworkID":   c.NetworkID,
+		"virtualNetworkCIDR": c.CIDR,
+	}
+}
+
+// providerSettings returns the provider specific settings of the cloud. The
+// settings of a cloud can't hold any field both AWS and Azure clouds have, as
+// they are decoded as a oneOf of the two.
+func (c *cloud) providerSettings() map[string]interface{} {
+	switch c.Provider {
+	case "azure":
+		return map[string]interface{}{
+			"resourceGroupName": "rg-" + c.NetworkID,
+			"region":            c.Region,
+			"vNetCidr":          c.CIDR,
+			"vNetName":          c.NetworkID,
+		}
+	case "aws":
+		return map[string]interface{}{
+			"region":  c.Region,
+			"vpcCidr": c.CIDR,
+			"vpcId":   c.NetworkID,
+		}
+	default:
+		return map[string]interface{}{
+			"vpcCidr": c.CIDR,
+			"vpcId":   c.NetworkID,
+		}
+	}
+}
+
+// AddCloud adds a ready cloud of the provider (aws, azure or gcp) to the server
+// and returns its ID.
+func (s *Server) AddCloud(provider, region, cidr string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := newID()
+	s.clouds[id] = &cloud{
+		ID:        id,
+		Name:      fmt.Sprintf("%s-%s", provider, id[:8]),
+		Provider:  provider,
+		Region:    region,
+		CIDR:      cidr,
+		NetworkID: "vpc-" + id[:8],
+		CreatedAt: s.now(),
+	}
+	return id
+}
+
+func (s *Server) listClouds(w http.ResponseWriter, r *http.Request, _ []string) {
+	clouds := make([]*cloud, 0, len(s.clouds))
+	for _, c := range s.clouds {
+		clouds = append(clouds, c)
+	}
+	sort.Slice(clouds, func(i, j int) bool { return clouds[i].CreatedAt.Before(clouds[j].CreatedAt) })
+
+	start, end, c := paginate(r, len(clouds))
+	data := make([]map[string]interface{}, 0, end-start)
+	for _, cloud := range clouds[start:end] {
+		data = append(data, cloud.summary())
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"cursor": c, "data": data})
+}
+
+func (s *Server) showCloud(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.clouds[params[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NotFound", fmt.Sprintf("cloud %s not found", params[0]))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":               c.ID,
+		"name":             c.Name,
+		"tenantId":         s.tenantID,
+		"status":           "ready",
+		"provider":         c.Provider,
+		"createdAt":        c.CreatedAt,
+		"providerSettings": c.providerSettings(),
+		"version":          map[string]interface{}{"name": "1.0.0", "components": map[string]string{}},
+	})
+}
@@ -0,0 +1,337 @@
+// Couchbase, Inc. licenses this to you under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at https://www.apache.org/licenses/LICENSE-2.0.
+
+// Unless required by applicable law or agreed to in writing, software distributed under the License
+// is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and limitations under the License.
+
+package capellamock
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	environmentVpc    = "vpc"
+	environmentHosted = "hosted"
+
+	// serverVersion is the Couchbase Server version of every cluster.
+	serverVersion = "7.1.1"
+)
+
+// cluster is a cluster deployed either in a cloud of the tenant (vpc) or in
+// the Capella cloud (hosted). The v2 API only serves vpc clusters and the
+// v3 API only serves hosted ones.
+type cluster struct {
+	ID          string
+	Name        string
+	Description string
+	Environment string
+	ProjectID   string
+	CloudID     string
+	Status      string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+
+	// VpcServers are the servers of a vpc cluster.
+	VpcServers []vpcServer
+	// Place, HostedServers and SupportPackage are the settings of a hosted cluster.
+	Place          hostedPlace
+	HostedServers  []hostedServer
+	SupportPackage supportPackage
+
+	pending *transition
+	buckets map[string]*bucket
+	users   map[string]*user
+}
+
+// transition is a status change of a cluster due at a given time.
+type transition struct {
+	status string
+	at     time.Time
+}
+
+type vpcServer struct {
+	Size     int             `json:"size"`
+	Services []string        `json:"services"`
+	Aws      *vpcServerAws   `json:"aws,omitempty"`
+	Azure    *vpcServerAzure `json:"azure,omitempty"`
+	Gcp      *vpcServerGcp   `json:"gcp,omitempty"`
+}
+
+type vpcServerAws struct {
+	InstanceSize string `json:"instanceSize"`
+	EbsSizeGib   int    `json:"ebsSizeGib"`
+}
+
+type vpcServerAzure struct {
+	InstanceSize string `json:"instanceSize"`
+	VolumeType   string `json:"volumeType"`
+}
+
+type vpcServerGcp struct {
+	InstanceSize string `json:"instanceSize"`
+	DiskSizeGib  int    `json:"diskSizeGib"`
+}
+
+func newCluster(environment, projectID, name string, now time.Time) *cluster {
+	return &cluster{
+		ID:          newID(),
+		Name:        name,
+		Environment: environment,
+		ProjectID:   projectID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		buckets:     make(map[string]*bucket),
+		users:       make(map[string]*user),
+	}
+}
+
+// endpoint returns the DNS SRV record of the cluster.
+func (c *cluster) endpoint() string {
+	return fmt.Sprintf("cb.%s.cloud.couchbase.com", c.ID[:8])
+}
+
+// ready reports whether the cluster is deployed and not changing.
+func (c *cluster) ready() bool {
+	return c.Status == "ready" || c.Status == "healthy"
+}
+
+// AddVpcCluster adds a ready vpc cluster with three data servers to a project
+// and a cloud of the server and returns its ID.
+func (s *Server) AddVpcCluster(projectID, cloudID, name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cloud, ok := s.clouds[cloudID]
+	if !ok {
+		panic(fmt.Sprintf("capellamock: unknown cloud %s", cloudID))
+	}
+	if _, ok := s.projects[projectID]; !ok {
+		panic(fmt.Sprintf("capellamock: unknown project %s", projectID))
+	}
+
+	c := newCluster(environmentVpc, projectID, name, s.now())
+	c.CloudID = cloudID
+	c.Status = "ready"
+	server := vpcServer{Size: 3, Services: []string{"data"}}
+	switch cloud.Provider {
+	case "azure":
+		server.Azure = &vpcServerAzure{InstanceSize: "Standard_F4s_v2", VolumeType: "P6"}
+	case "gcp":
+		server.Gcp = &vpcServerGcp{InstanceSize: "n2-standard-4", DiskSizeGib: 50}
+	default:
+		server.Aws = &vpcServerAws{InstanceSize: "m5.xlarge", EbsSizeGib: 50}
+	}
+	c.VpcServers = []vpcServer{server}
+	s.clusters[c.ID] = c
+	return c.ID
+}
+
+// ClusterStatus returns the status of a cluster, or an empty string once
+// the cluster is gone.
+func (s *Server) ClusterStatus(id string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.advance()
+	if c, ok := s.clusters[id]; ok {
+		return c.Status
+	}
+	return ""
+}
+
+// vpcCluster returns the vpc cluster of the request path, writing a 404
+// response if there isn't one.
+func (s *Server) vpcCluster(w http.ResponseWriter, id string) (*cluster, bool) {
+	c, ok := s.clusters[id]
+	if !ok || c.Environment != environmentVpc {
+		writeError(w, http.StatusNotFound, "NotFound", fmt.Sprintf("cluster %s not found", id))
+		return nil, false
+	}
+	return c, true
+}
+
+func (s *Server) listClusters(w http.ResponseWriter, r *http.Request, _ []string) {
+	clusters := make([]*cluster, 0, len(s.clusters))
+	for _, c := range s.clusters {
+		if c.Environment == environmentVpc {
+			clusters = append(clusters, c)
+		}
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].CreatedAt.Before(clusters[j].CreatedAt) })
+
+	start, end, page := paginate(r, len(clusters))
+	data := make([]map[string]interface{}, 0, end-start)
+	for _, c := range clusters[start:end] {
+		services := make([]string, 0)
+		nodes := 0
+		for _, server := range c.VpcServers {
+			nodes += server.Size
+			for _, service := range server.Services {
+				if !contains(services, service) {
+					services = append(services, service)
+				}
+			}
+		}
+		data = append(data, map[string]interface{}{
+			"id":        c.ID,
+			"name":      c.Name,
+			"tenantId":  s.tenantID,
+			"cloudId":   c.CloudID,
+			"projectId": c.ProjectID,
+			"services":  services,
+			"nodes":     nodes,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"cursor": page, "data": data})
+}
+
+func (s *Server) createCluster(w http.ResponseWriter, r *http.Request, _ []string) {
+	var request struct {
+		Name      string      `json:"name"`
+		CloudID   string      `json:"cloudId"`
+		ProjectID string      `json:"projectId"`
+		Servers   []vpcServer `json:"servers"`
+	}
+	if !decodeBody(w, r, &request) {
+		return
+	}
+
+	if strings.TrimSpace(request.Name) == "" {
+		writeError(w, http.StatusUnprocessableEntity, "UnprocessableEntity", "name must be set")
+		return
+	}
+	if _, ok := s.projects[request.ProjectID]; !ok {
+		writeError(w, http.StatusUnprocessableEntity, "UnprocessableEntity", fmt.Sprintf("project %s not found", request.ProjectID))
+		return
+	}
+	cloud, ok := s.clouds[request.CloudID]
+	if !ok {
+		writeError(w, http.StatusUnprocessableEntity, "UnprocessableEntity", fmt.Sprintf("cloud %s not found", request.CloudID))
+		return
+	}
+	if message := validateVpcServers(request.Servers, cloud.Provider); message != "" {
+		writeError(w, http.StatusUnprocessableEntity, "UnprocessableEntity", message)
+		return
+	}
+
+	c := newCluster(environmentVpc, request.ProjectID, request.Name, s.now())
+	c.CloudID = request.CloudID
+	c.VpcServers = request.Servers
+	s.transition(c, "deploying", "ready", s.DeployDuration)
+	s.clusters[c.ID] = c
+
+	w.Header().Set("Location", "/v2/clusters/"+c.ID)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) showCluster(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.vpcCluster(w, params[0])
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":                  c.ID,
+		"name":                c.Name,
+		"tenantId":            s.tenantID,
+		"cloudId":             c.CloudID,
+		"projectId":           c.ProjectID,
+		"status":              c.Status,
+		"resourceIdentifier":  "cluster-" + c.ID[:8],
+		"createdAt":           c.CreatedAt,
+		"updatedAt":           c.UpdatedAt,
+		"version":             map[string]interface{}{"name": serverVersion, "components": map[string]string{"cbServerVersion": serverVersion}},
+		"endpointsURL":        []string{"https://" + c.endpoint()},
+		"privateEndpointURL":  []string{"https://private-" + c.endpoint()},
+		"endpointsSrv":        c.endpoint(),
+		"privateEndpointsSrv": "private-" + c.endpoint(),
+		"servers":             c.VpcServers,
+	})
+}
+
+func (s *Server) updateClusterServers(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.vpcCluster(w, params[0])
+	if !ok {
+		return
+	}
+	var request struct {
+		Servers []vpcServer `json:"servers"`
+	}
+	if !decodeBody(w, r, &request) {
+		return
+	}
+
+	if !c.ready() {
+		writeError(w, http.StatusUnprocessableEntity, "UnprocessableEntity", fmt.Sprintf("cluster %s can't be scaled while %s", c.ID, c.Status))
+		return
+	}
+	if len(request.Servers) == 0 {
+		writeError(w, http.StatusUnprocessableEntity, "UnprocessableEntity", "servers must be set")
+		return
+	}
+	provider := ""
+	if cloud, ok := s.clouds[c.CloudID]; ok {
+		provider = cloud.Provider
+	}
+	if message := validateVpcServers(request.Servers, provider); message != "" {
+		writeError(w, http.StatusUnprocessableEntity, "UnprocessableEntity", message)
+		return
+	}
+
+	c.VpcServers = request.Servers
+	s.transition(c, "deploying", "ready", s.ScaleDuration)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// validateVpcServers returns why the servers of a vpc cluster in a cloud of the
+// given provider are invalid, or an empty string if they are valid.
+func validateVpcServers(servers []vpcServer, provider string) string {
+	for _, server := range servers {
+		if server.Size < 3 || len(server.Services) == 0 {
+			return "servers must have a size of at least 3 and at least one service"
+		}
+		if (server.Aws != nil && provider != "aws") || (server.Azure != nil && provider != "azure") || (server.Gcp != nil && provider != "gcp") {
+			return fmt.Sprintf("servers must be set for the %s provider of the cloud", provider)
+		}
+	}
+	return ""
+}
+
+func (s *Server) deleteCluster(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.vpcCluster(w, params[0])
+	if !ok {
+		return
+	}
+	if !c.ready() {
+		writeError(w, http.StatusUnprocessableEntity, "UnprocessableEntity", fmt.Sprintf("cluster %s can't be deleted while %s", c.ID, c.Status))
+		return
+	}
+
+	s.transition(c, "destroying", "", s.DestroyDuration)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) clusterStatus(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.vpcCluster(w, params[0])
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": c.Status})
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,318 @@
+// Couchbase, Inc. licenses this to you under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at https://www.apache.org/licenses/LICENSE-2.0.
+
+// Unless required by applicable law or agreed to in writing, software distributed under the License
+// is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and limitations under the License.
+
+package capellamock
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+type hostedPlace struct {
+	SingleAZ bool   `json:"singleAZ"`
+	Provider string `json:"provider"`
+	Region   string `json:"region"`
+	CIDR     string `json:"CIDR"`
+}
+
+type hostedServer struct {
+	Size     int           `json:"size"`
+	Compute  string        `json:"compute"`
+	Services []string      `json:"services"`
+	Storage  hostedStorage `json:"storage"`
+}
+
+type hostedStorage struct {
+	Type string `json:"type"`
+	IOPS int    `json:"IOPS,omitempty"`
+	Size int    `json:"size"`
+}
+
+type supportPackage struct {
+	Timezone string `json:"timezone"`
+	Type     string `json:"type"`
+}
+
+// AddHostedCluster adds a healthy hosted cluster with three data servers to a
+// project of the server and returns its ID.
+func (s *Server) AddHostedCluster(projectID, name, provider, region, cidr string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.projects[projectID]; !ok {
+		panic(fmt.Sprintf("capellamock: unknown project %s", projectID))
+	}
+
+	c := newCluster(environmentHosted, projectID, name, s.now())
+	c.Status = "healthy"
+	c.Place = hostedPlace{SingleAZ: true, Provider: provider, Region: region, CIDR: cidr}
+	storage := hostedStorage{Type: "GP3", IOPS: 3000, Size: 50}
+	compute := "m5.xlarge"
+	if provider == "gcp" {
+		storage = hostedStorage{Type: "PD-SSD", Size: 50}
+		compute = "n2-standard-4"
+	}
+	c.HostedServers = []hostedServer{{Size: 3, Compute: compute, Services: []string{"data"}, Storage: storage}}
+	c.SupportPackage = supportPackage{Timezone: "GMT", Type: "Basic"}
+	s.clusters[c.ID] = c
+	return c.ID
+}
+
+// hostedCluster returns the hosted cluster of the request path, writing a
+// 404 response if there isn't one.
+func (s *Server) hostedCluster(w http.ResponseWriter, id string) (*cluster, bool) {
+	c, ok := s.clusters[id]
+	if !ok || c.Environment != environmentHosted {
+		writeV3Error(w, http.StatusNotFound, "", fmt.Sprintf("cluster %s not found", id))
+		return nil, false
+	}
+	return c, true
+}
+
+// validateHostedServers returns the request field and message of the first
+// invalid server, if any.
+func validateHostedServers(servers []hostedServer, provider string) (string, string) {
+	if len(servers) == 0 {
+		return "servers", "at least one server must be set"
+	}
+	for i, server := range servers {
+		field := fmt.Sprintf("servers[%d]", i)
+		if server.Size < 1 || server.Compute == "" || len(server.Services) == 0 {
+			return field, "servers must have a size, a compute and at least one service"
+		}
+		if provider == "gcp" && server.Storage.IOPS != 0 {
+			return field + ".storage.IOPS", "IOPS can't be set for GCP storage"
+		}
+		if server.Storage.Size < 1 {
+			return field + ".storage.size", "storage size must be set"
+		}
+	}
+	return "", ""
+}
+
+// availabilityZones returns the availability zones the cluster is deployed in.
+func (c *cluster) availabilityZones() []string {
+	zones := []string{c.Place.Region + "a"}
+	if !c.Place.SingleAZ {
+		zones = append(zones, c.Place.Region+"b", c.Place.Region+"c")
+	}
+	return zones
+}
+
+func (s *Server) listHostedClusters(w http.ResponseWriter, r *http.Request, _ []string) {
+	clusters := make([]*cluster, 0, len(s.clusters))
+	for _, c := range s.clusters {
+		if c.Environment == environmentHosted {
+			clusters = append(clusters, c)
+		}
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].CreatedAt.Before(clusters[j].CreatedAt) })
+
+	start, end, page := paginate(r, len(clusters))
+	items := make([]map[string]interface{}, 0, end-start)
+	for _, c := range clusters[start:end] {
+		items = append(items, map[string]interface{}{
+			"environment": c.Environment,
+			"id":          c.ID,
+			"name":        c.Name,
+			"projectId":   c.ProjectID,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"cursor": page,
+		"data":   map[string]interface{}{"tenantId": s.tenantID, "items": items},
+	})
+}
+
+func (s *Server) createHostedCluster(w http.ResponseWriter, r *http.Request, _ []string) {
+	var request struct {
+		Environment string `json:"environment"`
+		ClusterName string `json:"clusterName"`
+		ProjectID   string `json:"projectId"`
+		Description string `json:"description"`
+		Place       struct {
+			SingleAZ bool         `json:"singleAZ"`
+			Hosted   *hostedPlace `json:"hosted"`
+		} `json:"place"`
+		Servers        []hostedServer `json:"servers"`
+		SupportPackage supportPackage `json:"supportPackage"`
+	}
+	if !decodeBody(w, r, &request) {
+		return
+	}
+
+	if request.Environment != environmentHosted {
+		writeV3Error(w, http.StatusUnprocessableEntity, "environment", "only hosted clusters can be created")
+		return
+	}
+	if strings.TrimSpace(request.ClusterName) == "" {
+		writeV3Error(w, http.StatusUnprocessableEntity, "clusterName", "clusterName must be set")
+		return
+	}
+	if _, ok := s.projects[request.ProjectID]; !ok {
+		writeV3Error(w, http.StatusUnprocessableEntity, "projectId", fmt.Sprintf("project %s not found", request.ProjectID))
+		return
+	}
+	hosted := request.Place.Hosted
+	if hosted == nil || hosted.Provider == "" || hosted.Region == "" {
+		writeV3Error(w, http.StatusUnprocessableEntity, "place.hosted", "the provider and region of the cluster must be set")
+		return
+	}
+	if hosted.CIDR == "" {
+		writeV3Error(w, http.StatusUnprocessableEntity, "place.CIDR", "the CIDR of the cluster must be set")
+		return
+	}
+	if field, message := validateHostedServers(request.Servers, hosted.Provider); field != "" {
+		writeV3Error(w, http.StatusUnprocessableEntity, field, message)
+		return
+	}
+	if request.SupportPackage.Type == "" {
+		writeV3Error(w, http.StatusUnprocessableEntity, "supportPackage.type", "the support package type must be set")
+		return
+	}
+
+	c := newCluster(environmentHosted, request.ProjectID, request.ClusterName, s.now())
+	c.Description = request.Description
+	c.Place = *hosted
+	c.Place.SingleAZ = request.Place.SingleAZ
+	c.HostedServers = request.Servers
+	c.SupportPackage = request.SupportPackage
+	s.transition(c, "deploying", "healthy", s.DeployDuration)
+	s.clusters[c.ID] = c
+
+	w.Header().Set("Location", "/v3/clusters/"+c.ID)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) showHostedCluster(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.hostedCluster(w, params[0])
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":                c.ID,
+		"name":              c.Name,
+		"description":       c.Description,
+		"tenantId":          s.tenantID,
+		"projectId":         c.ProjectID,
+		"createdAt":         c.CreatedAt,
+		"updatedAt":         c.UpdatedAt,
+		"status":            c.Status,
+		"version":           map[string]interface{}{"name": serverVersion, "components": map[string]string{"cbServerVersion": serverVersion}},
+		"endpointsSrv":      c.endpoint(),
+		"environment":       c.Environment,
+		"place":             c.Place,
+		"servers":           c.HostedServers,
+		"availabilityZones": c.availabilityZones(),
+		"support":           c.SupportPackage.Type,
+		"supportPackage":    c.SupportPackage,
+	})
+}
+
+func (s *Server) deleteHostedCluster(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.hostedCluster(w, params[0])
+	if !ok {
+		return
+	}
+	if !c.ready() {
+		writeV3Error(w, http.StatusUnprocessableEntity, "", fmt.Sprintf("cluster %s can't be deleted while %s", c.ID, c.Status))
+		return
+	}
+
+	s.transition(c, "destroying", "", s.DestroyDuration)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) hostedClusterStatus(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.hostedCluster(w, params[0])
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": c.Status})
+}
+
+func (s *Server) updateHostedClusterMeta(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.hostedCluster(w, params[0])
+	if !ok {
+		return
+	}
+	var request struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+	}
+	if !decodeBody(w, r, &request) {
+		return
+	}
+
+	if request.Name != nil {
+		if strings.TrimSpace(*request.Name) == "" {
+			writeV3Error(w, http.StatusUnprocessableEntity, "name", "name can't be empty")
+			return
+		}
+		c.Name = *request.Name
+	}
+	if request.Description != nil {
+		c.Description = *request.Description
+	}
+	c.UpdatedAt = s.now()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) updateHostedClusterServers(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.hostedCluster(w, params[0])
+	if !ok {
+		return
+	}
+	var request struct {
+		Servers []hostedServer `json:"servers"`
+	}
+	if !decodeBody(w, r, &request) {
+		return
+	}
+
+	if !c.ready() {
+		writeV3Error(w, http.StatusUnprocessableEntity, "", fmt.Sprintf("cluster %s can't be scaled while %s", c.ID, c.Status))
+		return
+	}
+	if field, message := validateHostedServers(request.Servers, c.Place.Provider); field != "" {
+		writeV3Error(w, http.StatusUnprocessableEntity, field, message)
+		return
+	}
+
+	c.HostedServers = request.Servers
+	s.transition(c, "scaling", "healthy", s.ScaleDuration)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) updateHostedClusterSupport(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.hostedCluster(w, params[0])
+	if !ok {
+		return
+	}
+	var request struct {
+		SupportPackage supportPackage `json:"supportPackage"`
+	}
+	if !decodeBody(w, r, &request) {
+		return
+	}
+
+	if request.SupportPackage.Type == "" {
+		writeV3Error(w, http.StatusUnprocessableEntity, "supportPackage.type", "the support package type must be set")
+		return
+	}
+	c.SupportPackage.Type = request.SupportPackage.Type
+	if request.SupportPackage.Timezone != "" {
+		c.SupportPackage.Timezone = request.SupportPackage.Timezone
+	}
+	c.UpdatedAt = s.now()
+	w.WriteHeader(http.StatusNoContent)
+}
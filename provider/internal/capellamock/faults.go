@@ -0,0 +1,150 @@
+// Couchbase, Inc. licenses this to you under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at https://www.apache.org/licenses/LICENSE-2.0.
+
+// Unless required by applicable law or agreed to in writing, software distributed under the License
+// is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and limitations under the License.
+
+package capellamock
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Fault is a failure the server injects into its responses to the requests the
+// fault matches. Faults are matched in the order they were injected, and only
+// the first fault matching a request applies to it.
+type Fault struct {
+	// Method is the method of the requests the fault applies to, or empty
+	// for any method.
+	Method string
+	// Path is the path of the requests the fault applies to, without the
+	// leading slash. A * segment matches any segment, as in "v3/clusters/*/status".
+	// An empty Path matches any request.
+	Path string
+	// Count is the number of requests the fault applies to before it is
+	// removed, or 0 for it to apply until ClearFaults is called.
+	Count int
+
+	// Status is the status code of the error response sent in place of
+	// handling the request, such as 429 or 500. When 0, the request is handled.
+	Status int
+	// RetryAfter is the value of the Retry-After header of the error response,
+	// in seconds or as an HTTP date. The header isn't sent when empty.
+	RetryAfter string
+	// Delay is how long the server waits before responding.
+	Delay time.Duration
+	// EmptyBody drops the body of the response, leaving its status and headers.
+	EmptyBody bool
+}
+
+// Inject adds a fault to the server.
+func (s *Server) Inject(fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, &faultState{Fault: fault, pattern: splitPath(fault.Path), remaining: fault.Count})
+}
+
+// ClearFaults removes all the faults of the server.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = nil
+}
+
+// SetClusterStatus sets the status of a cluster and cancels its pending status
+// transition, leaving it in that status until it is changed through the API.
+// Setting the status of a new cluster to "deploying" leaves it stuck deploying.
+func (s *Server) SetClusterStatus(id, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.clusters[id]
+	if !ok {
+		panic(fmt.Sprintf("capellamock: unknown cluster %s", id))
+	}
+	c.Status = status
+	c.pending = nil
+}
+
+// faultState is an injected fault along with the number of requests it still applies to.
+type faultState struct {
+	Fault
+	pattern   []string
+	remaining int
+}
+
+// takeFault returns the fault applying to the request, if any.
+func (s *Server) takeFault(r *http.Request) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	segments := splitPath(r.URL.Path)
+	for i, f := range s.faults {
+		if f.Method != "" && f.Method != r.Method {
+			continue
+		}
+		if f.Path != "" {
+			if _, ok := matchPath(f.pattern, segments); !ok {
+				continue
+			}
+		}
+
+		if f.Count > 0 {
+			f.remaining--
+			if f.remaining == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+		fault := f.Fault
+		return &fault
+	}
+	return nil
+}
+
+// injectFault applies a fault to the response of a request. It reports whether
+// the fault answered the request, in which case the request must not be handled.
+func injectFault(w http.ResponseWriter, r *http.Request, fault *Fault) (http.ResponseWriter, bool) {
+	if fault.Delay > 0 {
+		timer := time.NewTimer(fault.Delay)
+		defer timer.Stop()
+		select {
+		case <-r.Context().Done():
+			return w, true
+		case <-timer.C:
+		}
+	}
+
+	if fault.EmptyBody {
+		w = emptyBodyWriter{w}
+	}
+	if fault.Status == 0 {
+		return w, false
+	}
+
+	if fault.RetryAfter != "" {
+		w.Header().Set("Retry-After", fault.RetryAfter)
+	}
+	writeError(w, fault.Status, errorType(fault.Status), fmt.Sprintf("injected %d fault", fault.Status))
+	return w, true
+}
+
+// emptyBodyWriter is an http.ResponseWriter that drops the body of the response.
+type emptyBodyWriter struct {
+	http.ResponseWriter
+}
+
+// Write implements the http.ResponseWriter interface.
+func (w emptyBodyWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
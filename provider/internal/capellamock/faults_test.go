@@ -0,0 +1,150 @@
+// Couchbase, Inc. licenses this to you under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at https://www.apache.org/licenses/LICENSE-2.0.
+
+// Unless required by applicable law or agreed to in writing, software distributed under the License
+// is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and limitations under the License.
+
+package capellamock
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	couchbasecapella "github.com/couchbasecloud/couchbase-capella-api-go-client"
+)
+
+// Test to see if an error fault answers the requests it matches until its count runs out
+func TestServer_faultStatus(t *testing.T) {
+	s, client, ctx, _ := newTestServer(t)
+	projectID := s.AddProject("project")
+	s.Inject(Fault{Method: http.MethodGet, Path: "v2/projects/*", Count: 2, Status: http.StatusTooManyRequests, RetryAfter: "3"})
+
+	// The fault doesn't match listing the projects
+	if _, _, err := client.ProjectsApi.ProjectsList(ctx).Execute(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	for i := 0; i < 2; i++ {
+		_, r, err := client.ProjectsApi.ProjectsShow(ctx, projectID).Execute()
+		if err == nil || r.StatusCode != http.StatusTooManyRequests || r.Header.Get("Retry-After") != "3" {
+			t.Fatalf("expected a 429 response with a Retry-After header, got %v", err)
+		}
+	}
+	if _, _, err := client.ProjectsApi.ProjectsShow(ctx, projectID).Execute(); err != nil {
+		t.Fatalf("expected the fault to be exhausted, got %s", err)
+	}
+
+	s.Inject(Fault{Status: http.StatusInternalServerError})
+	for i := 0; i < 3; i++ {
+		if _, r, err := client.ProjectsApi.ProjectsShow(ctx, projectID).Execute(); err == nil || r.StatusCode != http.StatusInternalServerError {
+			t.Fatalf("expected a 500 response, got %v", err)
+		}
+	}
+	s.ClearFaults()
+	if _, _, err := client.ProjectsApi.ProjectsShow(ctx, projectID).Execute(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+// Test to see if an empty body fault keeps the status of the response but drops its body
+func TestServer_faultEmptyBody(t *testing.T) {
+	s, _, _, _ := newTestServer(t)
+	clusterID := s.AddVpcCluster(s.AddProject("project"), s.AddCloud("aws", "us-east-1", "10.0.0.0/16"), "cluster")
+	s.Inject(Fault{Path: "v2/clusters/*/status", Count: 1, EmptyBody: true})
+
+	// The request is signed by hand rather than sent with the client so that the raw body can be checked
+	path := "/v2/clusters/" + clusterID + "/status"
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(DefaultSecretKey))
+	mac.Write([]byte(http.MethodGet + "\n" + path + "\n" + timestamp))
+	req, err := http.NewRequest(http.MethodGet, s.URL+path, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+DefaultAccessKey+":"+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	req.Header.Set("Couchbase-Timestamp", timestamp)
+	r, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil || r.StatusCode != http.StatusOK || len(body) != 0 {
+		t.Fatalf("expected an empty 200 response, got %d %q: %v", r.StatusCode, body, err)
+	}
+}
+
+// Test to see if a delayed response gives up when the request is cancelled
+func TestServer_faultDelay(t *testing.T) {
+	s, client, ctx, _ := newTestServer(t)
+	s.Inject(Fault{Path: "v2/projects", Delay: time.Minute})
+
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, _, err := client.ProjectsApi.ProjectsList(ctx).Execute(); err == nil {
+		t.Fatal("expected the request to time out")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("expected the request to be cancelled, it took %s", elapsed)
+	}
+}
+
+// Test to see if new buckets and users only show up in their lists once the list delay has passed
+func TestServer_listDelay(t *testing.T) {
+	s, client, ctx, elapse := newTestServer(t)
+	s.ListDelay = 10 * time.Second
+	clusterID := s.AddVpcCluster(s.AddProject("project"), s.AddCloud("aws", "us-east-1", "10.0.0.0/16"), "cluster")
+
+	if _, _, err := client.ClustersApi.ClustersCreateBucket(ctx, clusterID).CouchbaseBucketSpec(*couchbasecapella.NewCouchbaseBucketSpec("bucket", 128)).Execute(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	request := couchbasecapella.NewCreateDatabaseUserRequest("user", "Password123!")
+	request.SetAllBucketsAccess(couchbasecapella.BUCKETROLETYPES_READER)
+	if _, err := client.ClustersApi.ClustersCreateUser(ctx, clusterID).CreateDatabaseUserRequest(*request).Execute(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	buckets, _, err := client.ClustersApi.ClustersListBuckets(ctx, clusterID).Execute()
+	if err != nil || len(buckets) != 0 {
+		t.Fatalf("expected no buckets yet, got %+v: %v", buckets, err)
+	}
+	users, _, err := client.ClustersApi.ClustersListUsers(ctx, clusterID).Execute()
+	if err != nil || len(users) != 0 {
+		t.Fatalf("expected no users yet, got %+v: %v", users, err)
+	}
+
+	elapse(10 * time.Second)
+	buckets, _, err = client.ClustersApi.ClustersListBuckets(ctx, clusterID).Execute()
+	if err != nil || len(buckets) != 1 {
+		t.Fatalf("expected the created bucket, got %+v: %v", buckets, err)
+	}
+	users, _, err = client.ClustersApi.ClustersListUsers(ctx, clusterID).Execute()
+	if err != nil || len(users) != 1 {
+		t.Fatalf("expected the created user, got %+v: %v", users, err)
+	}
+}
+
+// Test to see if a cluster left deploying never becomes ready
+func TestServer_setClusterStatus(t *testing.T) {
+	s, client, ctx, elapse := newTestServer(t)
+	clusterID := s.AddVpcCluster(s.AddProject("project"), s.AddCloud("aws", "us-east-1", "10.0.0.0/16"), "cluster")
+
+	s.SetClusterStatus(clusterID, "deploying")
+	elapse(time.Hour)
+	status, _, err := client.ClustersApi.ClustersStatus(ctx, clusterID).Execute()
+	if err != nil || status.Status != couchbasecapella.CLUSTERSTATUS_DEPLOYING {
+		t.Fatalf("expected the cluster to still be deploying, got %+v: %v", status, err)
+	}
+	if r, err := client.ClustersApi.ClustersDelete(ctx, clusterID).Execute(); err == nil || r.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("expected a 422 response, got %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+// Couchbase, Inc. licenses this to you under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at https://www.apache.org/licenses/LICENSE-2.0.
+
+// Unless required by applicable law or agreed to in writing, software distributed under the License
+// is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and limitations under the License.
+
+package capellamock
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+type project struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	TenantID  string    `json:"tenantId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// AddProject adds a project to the server and returns its ID.
+func (s *Server) AddProject(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addProject(name).ID
+}
+
+func (s *Server) addProject(name string) *project {
+	p := &project{ID: newID(), Name: name, TenantID: s.tenantID, CreatedAt: s.now()}
+	s.projects[p.ID] = p
+	return p
+}
+
+func (s *Server) listProjects(w http.ResponseWriter, r *http.Request, _ []string) {
+	projects := make([]*project, 0, len(s.projects))
+	for _, p := range s.projects {
+		projects = append(projects, p)
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].CreatedAt.Before(projects[j].CreatedAt) })
+
+	start, end, c := paginate(r, len(projects))
+	writeJSON(w, http.StatusOK, map[string]interface{}{"cursor": c, "data": projects[start:end]})
+}
+
+func (s *Server) createProject(w http.ResponseWriter, r *http.Request, _ []string) {
+	var request struct {
+		Name string `json:"name"`
+	}
+	if !decodeBody(w, r, &request) {
+		return
+	}
+	if strings.TrimSpace(request.Name) == "" {
+		writeError(w, http.StatusUnprocessableEntity, "UnprocessableEntity", "name must be set")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, s.addProject(request.Name))
+}
+
+func (s *Server) showProject(w http.ResponseWriter, r *http.Request, params []string) {
+	p, ok := s.projects[params[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NotFound", fmt.Sprintf("project %s not found", params[0]))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (s *Server) deleteProject(w http.ResponseWriter, r *http.Request, params []string) {
+	if _, ok := s.projects[params[0]]; !ok {
+		writeError(w, http.StatusNotFound, "NotFound", fmt.Sprintf("project %s not found", params[0]))
+		return
+	}
+	for _, c := range s.clusters {
+		if c.ProjectID == params[0] {
+			writeError(w, http.StatusBadRequest, "BadRequest", "the project still has clusters associated with it")
+			return
+		}
+	}
+
+	delete(s.projects, params[0])
+	w.WriteHeader(http.StatusNoContent)
+}
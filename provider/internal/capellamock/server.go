@@ -0,0 +1,397 @@
+// Couchbase, Inc. licenses this to you under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at https://www.apache.org/licenses/LICENSE-2.0.
+
+// Unless required by applicable law or agreed to in writing, software distributed under the License
+// is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and limitations under the License.
+
+// Package capellamock provides an in-memory stand-in for the Couchbase Capella
+// public API, so the provider can be tested without credentials or network access.
+//
+// The server implements the projects, clouds, v2 clusters, v3 clusters, buckets
+// and database users endpoints used by the provider. Requests must be signed
+// with the server API keys exactly like the Capella API expects them to be.
+// Clusters go through the same asynchronous status transitions as real ones:
+// they are deploying for DeployDuration after being created, scaling for
+// ScaleDuration after their servers are updated and destroying for
+// DestroyDuration before disappearing.
+//
+// Failures can be injected into the responses of the server to exercise the
+// error handling of the provider: throttling, server errors, slow responses,
+// responses without a body, lists that are only eventually consistent and
+// clusters stuck in a status. See Fault, ListDelay and SetClusterStatus.
+package capellamock
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultAccessKey is the access key requests are signed with unless
+	// the AccessKey of the server is changed.
+	DefaultAccessKey = "mock-access-key"
+	// DefaultSecretKey is the secret key requests are signed with unless
+	// the SecretKey of the server is changed.
+	DefaultSecretKey = "mock-secret-key"
+
+	defaultTransitionDuration = time.Second
+	defaultPerPage            = 10
+)
+
+// Server is an in-memory Capella API served over HTTP. Its settings must be
+// changed before it receives its first request.
+type Server struct {
+	*httptest.Server
+
+	// AccessKey and SecretKey are the API keys requests must be signed with.
+	AccessKey string
+	SecretKey string
+
+	// DeployDuration is how long a new cluster stays deploying.
+	DeployDuration time.Duration
+	// ScaleDuration is how long a hosted cluster stays scaling after its
+	// servers are updated.
+	ScaleDuration time.Duration
+	// DestroyDuration is how long a deleted cluster stays destroying.
+	DestroyDuration time.Duration
+	// ListDelay is how long new buckets and database users take to appear
+	// in the lists of their cluster.
+	ListDelay time.Duration
+
+	mu       sync.Mutex
+	now      func() time.Time
+	routes   []route
+	tenantID string
+	projects map[string]*project
+	clouds   map[string]*cloud
+	clusters map[string]*cluster
+	faults   []*faultState
+}
+
+// NewServer starts and returns a new Server with no data. The caller should
+// call Close when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{
+		AccessKey:       DefaultAccessKey,
+		SecretKey:       DefaultSecretKey,
+		DeployDuration:  defaultTransitionDuration,
+		ScaleDuration:   defaultTransitionDuration,
+		DestroyDuration: defaultTransitionDuration,
+		now:             time.Now,
+		tenantID:        newID(),
+		projects:        make(map[string]*project),
+		clouds:          make(map[string]*cloud),
+		clusters:        make(map[string]*cluster),
+	}
+	s.routes = s.apiRoutes()
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// handlerFunc handles a request with the path parameters matched by its route.
+type handlerFunc func(w http.ResponseWriter, r *http.Request, params []string)
+
+// route maps a method and a path pattern to a handler. A * segment of the
+// pattern matches any path segment, which is passed to the handler.
+type route struct {
+	method  string
+	pattern []string
+	handler handlerFunc
+}
+
+// apiRoutes returns the routes of the Capella API implemented by the server.
+func (s *Server) apiRoutes() []route {
+	routes := []struct {
+		method  string
+		pattern string
+		handler handlerFunc
+	}{
+		{http.MethodGet, "v2/projects", s.listProjects},
+		{http.MethodPost, "v2/projects", s.createProject},
+		{http.MethodGet, "v2/projects/*", s.showProject},
+		{http.MethodDelete, "v2/projects/*", s.deleteProject},
+
+		{http.MethodGet, "v2/clouds", s.listClouds},
+		{http.MethodGet, "v2/clouds/*", s.showCloud},
+
+		{http.MethodGet, "v2/clusters", s.listClusters},
+		{http.MethodPost, "v2/clusters", s.createCluster},
+		{http.MethodGet, "v2/clusters/*", s.showCluster},
+		{http.MethodDelete, "v2/clusters/*", s.deleteCluster},
+		{http.MethodGet, "v2/clusters/*/status", s.clusterStatus},
+		{http.MethodPut, "v2/clusters/*/servers", s.updateClusterServers},
+
+		{http.MethodGet, "v2/clusters/*/buckets", s.listBuckets},
+		{http.MethodPost, "v2/clusters/*/buckets", s.createBucket},
+		{http.MethodPut, "v2/clusters/*/buckets", s.replaceBucket},
+		{http.MethodDelete, "v2/clusters/*/buckets", s.deleteBucketByName},
+		{http.MethodPut, "v2/clusters/*/buckets/*", s.updateSingleBucket},
+		{http.MethodDelete, "v2/clusters/*/buckets/*", s.deleteBucket},
+
+		{http.MethodGet, "v2/clusters/*/users", s.listUsers},
+		{http.MethodPost, "v2/clusters/*/users", s.createUser},
+		{http.MethodPut, "v2/clusters/*/users/*", s.updateUser},
+		{http.MethodDelete, "v2/clusters/*/users/*", s.deleteUser},
+
+		{http.MethodGet, "v3/clusters", s.listHostedClusters},
+		{http.MethodPost, "v3/clusters", s.createHostedCluster},
+		{http.MethodGet, "v3/clusters/*", s.showHostedCluster},
+		{http.MethodDelete, "v3/clusters/*", s.deleteHostedCluster},
+		{http.MethodGet, "v3/clusters/*/status", s.hostedClusterStatus},
+		{http.MethodPut, "v3/clusters/*/meta", s.updateHostedClusterMeta},
+		{http.MethodPut, "v3/clusters/*/servers", s.updateHostedClusterServers},
+		{http.MethodPut, "v3/clusters/*/support", s.updateHostedClusterSupport},
+
+		{http.MethodGet, "v3/clusters/*/buckets", s.listHostedBuckets},
+		{http.MethodPost, "v3/clusters/*/buckets", s.createHostedBucket},
+		{http.MethodPut, "v3/clusters/*/buckets/*", s.updateHostedBucket},
+		{http.MethodDelete, "v3/clusters/*/buckets/*", s.deleteHostedBucket},
+
+		{http.MethodGet, "v3/clusters/*/users", s.listHostedUsers},
+		{http.MethodPost, "v3/clusters/*/users", s.createHostedUser},
+		{http.MethodPut, "v3/clusters/*/users/*", s.updateHostedUser},
+		{http.MethodDelete, "v3/clusters/*/users/*", s.deleteHostedUser},
+	}
+
+	result := make([]route, len(routes))
+	for i, r := range routes {
+		result[i] = route{method: r.method, pattern: splitPath(r.pattern), handler: r.handler}
+	}
+	return result
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if fault := s.takeFault(r); fault != nil {
+		var answered bool
+		if w, answered = injectFault(w, r, fault); answered {
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "Unauthorized", "the request signature is missing or invalid")
+		return
+	}
+
+	s.advance()
+
+	segments := splitPath(r.URL.Path)
+	pathMatched := false
+	for _, route := range s.routes {
+		params, ok := matchPath(route.pattern, segments)
+		if !ok {
+			continue
+		}
+		pathMatched = true
+		if route.method == r.Method {
+			route.handler(w, r, params)
+			return
+		}
+	}
+	if pathMatched {
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", fmt.Sprintf("%s is not allowed on %s", r.Method, r.URL.Path))
+		return
+	}
+	writeError(w, http.StatusNotFound, "NotFound", fmt.Sprintf("%s does not exist", r.URL.Path))
+}
+
+// matchPath returns the segments matched by the * segments of pattern.
+func matchPath(pattern, segments []string) ([]string, bool) {
+	if len(pattern) != len(segments) {
+		return nil, false
+	}
+	var params []string
+	for i, segment := range pattern {
+		if segment == "*" {
+			params = append(params, segments[i])
+			continue
+		}
+		if segment != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// authorized reports whether the request is signed with the server API keys.
+// The bearer token is the access key followed by the base64 encoded HMAC-SHA256,
+// keyed with the secret key, of the method, the request URI and the timestamp.
+func (s *Server) authorized(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	parts := strings.SplitN(token, ":", 2)
+	if len(parts) != 2 || parts[0] != s.AccessKey {
+		return false
+	}
+
+	message := strings.Join([]string{r.Method, r.URL.RequestURI(), r.Header.Get("Couchbase-Timestamp")}, "\n")
+	mac := hmac.New(sha256.New, []byte(s.SecretKey))
+	mac.Write([]byte(message))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(parts[1]), []byte(signature))
+}
+
+// advance applies the status transitions of the clusters that are due.
+func (s *Server) advance() {
+	now := s.now()
+	for id, c := range s.clusters {
+		if c.pending == nil || now.Before(c.pending.at) {
+			continue
+		}
+		if c.pending.status == "" {
+			delete(s.clusters, id)
+			continue
+		}
+		c.Status = c.pending.status
+		c.UpdatedAt = c.pending.at
+		c.pending = nil
+	}
+}
+
+// transition moves a cluster to status right away and to next once duration has elapsed.
+// An empty next status removes the cluster.
+func (s *Server) transition(c *cluster, status, next string, duration time.Duration) {
+	c.Status = status
+	c.UpdatedAt = s.now()
+	c.pending = &transition{status: next, at: c.UpdatedAt.Add(duration)}
+}
+
+// apiError is the error payload of the v2 API.
+type apiError struct {
+	Message   string `json:"message"`
+	ErrorType string `json:"errorType"`
+}
+
+// v3Error is the error payload of the v3 API. Field names the request
+// field the error is about, if any.
+type v3Error struct {
+	Code           int    `json:"code"`
+	Hint           string `json:"hint,omitempty"`
+	Message        string `json:"message"`
+	Field          string `json:"field,omitempty"`
+	HTTPStatusCode int    `json:"httpStatusCode"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if body != nil {
+		_ = json.NewEncoder(w).Encode(body)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, errorType, message string) {
+	writeJSON(w, status, apiError{Message: message, ErrorType: errorType})
+}
+
+// errorType returns the error type of the v2 error payloads for a status code.
+func errorType(status int) string {
+	return strings.ReplaceAll(http.StatusText(status), " ", "")
+}
+
+func writeV3Error(w http.ResponseWriter, status int, field, message string) {
+	writeJSON(w, status, v3Error{Code: status*10 + 1, Message: message, Field: field, HTTPStatusCode: status})
+}
+
+// decodeBody decodes the JSON body of the request into v and writes a
+// 400 response if it can't.
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequest", fmt.Sprintf("invalid request body: %s", err))
+		return false
+	}
+	return true
+}
+
+// cursor is the pagination cursor returned along with the v2 and v3 lists.
+type cursor struct {
+	Pages cursorPages `json:"pages"`
+	Hrefs cursorHrefs `json:"hrefs"`
+}
+
+type cursorPages struct {
+	Page       int  `json:"page"`
+	Next       *int `json:"next,omitempty"`
+	Previous   *int `json:"previous,omitempty"`
+	Last       int  `json:"last"`
+	PerPage    int  `json:"perPage"`
+	TotalItems int  `json:"totalItems"`
+}
+
+type cursorHrefs struct {
+	First    string  `json:"first"`
+	Last     string  `json:"last"`
+	Previous *string `json:"previous,omitempty"`
+	Next     *string `json:"next,omitempty"`
+}
+
+// paginate returns the bounds of the page of a list of total items asked
+// for by the page and perPage query parameters, along with its cursor.
+func paginate(r *http.Request, total int) (int, int, cursor) {
+	page := queryInt(r, "page", 1)
+	perPage := queryInt(r, "perPage", defaultPerPage)
+	last := (total + perPage - 1) / perPage
+	if last == 0 {
+		last = 1
+	}
+
+	href := func(page int) string {
+		return fmt.Sprintf("%s?page=%d&perPage=%d", r.URL.Path, page, perPage)
+	}
+	c := cursor{
+		Pages: cursorPages{Page: page, Last: last, PerPage: perPage, TotalItems: total},
+		Hrefs: cursorHrefs{First: href(1), Last: href(last)},
+	}
+	if page > 1 {
+		previous, previousHref := page-1, href(page-1)
+		c.Pages.Previous, c.Hrefs.Previous = &previous, &previousHref
+	}
+	if page < last {
+		next, nextHref := page+1, href(page+1)
+		c.Pages.Next, c.Hrefs.Next = &next, &nextHref
+	}
+
+	start := (page - 1) * perPage
+	if start > total {
+		start = total
+	}
+	end := start + perPage
+	if end > total {
+		end = total
+	}
+	return start, end, c
+}
+
+func queryInt(r *http.Request, name string, fallback int) int {
+	value, err := strconv.Atoi(r.URL.Query().Get(name))
+	if err != nil || value < 1 {
+		return fallback
+	}
+	return value
+}
+
+// newID returns a random version 4 UUID, the format of the Capella resource IDs.
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,277 @@
+// Couchbase, Inc. licenses this to you under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at https://www.apache.org/licenses/LICENSE-2.0.
+
+// Unless required by applicable law or agreed to in writing, software distributed under the License
+// is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and limitations under the License.
+
+package capellamock
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	couchbasecapella "github.com/couchbasecloud/couchbase-capella-api-go-client"
+)
+
+// newTestServer starts a server whose clock only moves when the returned
+// function is called, along with a client signing its requests for it.
+func newTestServer(t *testing.T) (*Server, *couchbasecapella.APIClient, context.Context, func(time.Duration)) {
+	s := NewServer()
+	t.Cleanup(s.Close)
+
+	now := time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	elapse := func(d time.Duration) { now = now.Add(d) }
+
+	cfg := couchbasecapella.NewConfiguration()
+	cfg.Servers[0].URL = s.URL
+	ctx := context.WithValue(context.Background(), couchbasecapella.ContextAPIKeys, map[string]couchbasecapella.APIKey{
+		"accessKey": {Key: DefaultAccessKey},
+		"secretKey": {Key: DefaultSecretKey},
+	})
+	return s, couchbasecapella.NewAPIClient(cfg), ctx, elapse
+}
+
+// Test to see if requests that aren't signed with the API keys of the server are rejected
+func TestServer_unauthorized(t *testing.T) {
+	s, client, _, _ := newTestServer(t)
+	s.AddProject("project")
+
+	ctx := context.WithValue(context.Background(), couchbasecapella.ContextAPIKeys, map[string]couchbasecapella.APIKey{
+		"accessKey": {Key: DefaultAccessKey},
+		"secretKey": {Key: "wrong-secret-key"},
+	})
+	_, r, err := client.ProjectsApi.ProjectsList(ctx).Execute()
+	if err == nil || r.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected a 401 response, got %v", err)
+	}
+}
+
+func TestServer_projects(t *testing.T) {
+	s, client, ctx, _ := newTestServer(t)
+
+	project, _, err := client.ProjectsApi.ProjectsCreate(ctx).CreateProjectRequest(*couchbasecapella.NewCreateProjectRequest("project")).Execute()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	shown, _, err := client.ProjectsApi.ProjectsShow(ctx, project.Id).Execute()
+	if err != nil || shown.Name != "project" {
+		t.Fatalf("expected the created project, got %+v: %v", shown, err)
+	}
+	list, _, err := client.ProjectsApi.ProjectsList(ctx).Execute()
+	if err != nil || len(list.Data) != 1 || list.Cursor.Pages.TotalItems != 1 {
+		t.Fatalf("expected one project in the list, got %+v: %v", list, err)
+	}
+
+	// A project can't be deleted while it has clusters
+	cloudID := s.AddCloud("aws", "us-east-1", "10.0.0.0/16")
+	s.AddVpcCluster(project.Id, cloudID, "cluster")
+	r, err := client.ProjectsApi.ProjectsDelete(ctx, project.Id).Execute()
+	if err == nil || r.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected a 400 response, got %v", err)
+	}
+
+	empty := s.AddProject("empty")
+	if _, err := client.ProjectsApi.ProjectsDelete(ctx, empty).Execute(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, r, err := client.ProjectsApi.ProjectsShow(ctx, empty).Execute(); err == nil || r.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected a 404 response, got %v", err)
+	}
+}
+
+// Test to see if the clouds can be decoded by the API client
+func TestServer_clouds(t *testing.T) {
+	s, client, ctx, _ := newTestServer(t)
+
+	for _, provider := range []string{"aws", "azure"} {
+		region := "us-east-1"
+		if provider == "azure" {
+			region = "eastus"
+		}
+		id := s.AddCloud(provider, region, "10.0.0.0/16")
+		cloud, _, err := client.CloudsApi.CloudsShow(ctx, id).Execute()
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if string(cloud.Provider) != provider {
+			t.Fatalf("expected a %s cloud, got %s", provider, cloud.Provider)
+		}
+	}
+
+	list, _, err := client.CloudsApi.CloudsList(ctx).Execute()
+	if err != nil || len(list.Data) != 2 {
+		t.Fatalf("expected two clouds in the list, got %+v: %v", list, err)
+	}
+}
+
+// Test to see if a vpc cluster goes from deploying to ready and from destroying to gone
+func TestServer_vpcCluster(t *testing.T) {
+	s, client, ctx, elapse := newTestServer(t)
+	projectID := s.AddProject("project")
+	cloudID := s.AddCloud("aws", "us-east-1", "10.0.0.0/16")
+
+	request := couchbasecapella.NewCreateClusterRequest("cluster", cloudID, projectID)
+	request.SetServers([]couchbasecapella.Server{{
+		Size:     3,
+		Services: []couchbasecapella.CouchbaseServices{couchbasecapella.COUCHBASESERVICES_DATA},
+		Aws:      &couchbasecapella.ServerAws{InstanceSize: couchbasecapella.AWSINSTANCES_M5_XLARGE, EbsSizeGib: 50},
+	}})
+	r, err := client.ClustersApi.ClustersCreate(ctx).CreateClusterRequest(*request).Execute()
+	if err != nil || r.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected a 202 response, got %v", err)
+	}
+	location := strings.Split(r.Header.Get("Location"), "/")
+	id := location[len(location)-1]
+
+	status, _, err := client.ClustersApi.ClustersStatus(ctx, id).Execute()
+	if err != nil || status.Status != couchbasecapella.CLUSTERSTATUS_DEPLOYING {
+		t.Fatalf("expected the cluster to be deploying, got %+v: %v", status, err)
+	}
+	elapse(s.DeployDuration)
+	cluster, _, err := client.ClustersApi.ClustersShow(ctx, id).Execute()
+	if err != nil || cluster.Status != couchbasecapella.CLUSTERSTATUS_READY {
+		t.Fatalf("expected the cluster to be ready, got %+v: %v", cluster, err)
+	}
+
+	if _, err := client.ClustersApi.ClustersDelete(ctx, id).Execute(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if got := s.ClusterStatus(id); got != "destroying" {
+		t.Fatalf("expected the cluster to be destroying, got %q", got)
+	}
+	elapse(s.DestroyDuration)
+	if _, r, err := client.ClustersApi.ClustersStatus(ctx, id).Execute(); err == nil || r.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected a 404 response, got %v", err)
+	}
+}
+
+// Test to see if a hosted cluster goes through deploying, scaling and destroying
+// and is only served by the v3 API
+func TestServer_hostedCluster(t *testing.T) {
+	s, client, ctx, elapse := newTestServer(t)
+	projectID := s.AddProject("project")
+
+	place := *couchbasecapella.NewV3Place(true)
+	place.SetHosted(*couchbasecapella.NewV3PlaceHosted(couchbasecapella.V3PROVIDER_AWS, "us-west-2", "10.0.0.0/20"))
+	servers := []couchbasecapella.V3Servers{{
+		Size:     3,
+		Compute:  "m5.xlarge",
+		Services: []couchbasecapella.V3CouchbaseServices{couchbasecapella.V3COUCHBASESERVICES_DATA},
+		Storage:  couchbasecapella.V3ServersStorage{Type: couchbasecapella.V3STORAGETYPE_GP3, IOPS: couchbasecapella.PtrInt32(3000), Size: 50},
+	}}
+	supportPackage := *couchbasecapella.NewV3SupportPackage(couchbasecapella.V3SUPPORTPACKAGETIMEZONES_GMT, couchbasecapella.V3SUPPORTPACKAGETYPE_BASIC)
+	request := couchbasecapella.NewV3CreateClusterRequest(couchbasecapella.V3ENVIRONMENT_HOSTED, "cluster", projectID, place, servers, supportPackage)
+	r, err := client.ClustersV3Api.ClustersV3create(ctx).V3CreateClusterRequest(*request).Execute()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	location := strings.Split(r.Header.Get("Location"), "/")
+	id := location[len(location)-1]
+
+	if got := s.ClusterStatus(id); got != "deploying" {
+		t.Fatalf("expected the cluster to be deploying, got %q", got)
+	}
+	elapse(s.DeployDuration)
+	cluster, _, err := client.ClustersV3Api.ClustersV3show(ctx, id).Execute()
+	if err != nil || cluster.Status != "healthy" || cluster.Place.CIDR != "10.0.0.0/20" || cluster.Servers[0].Storage.IOPS != 3000 {
+		t.Fatalf("expected the cluster to be healthy, got %+v: %v", cluster, err)
+	}
+	if _, r, err := client.ClustersApi.ClustersShow(ctx, id).Execute(); err == nil || r.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected the v2 API not to serve hosted clusters, got %v", err)
+	}
+
+	servers[0].Size = 5
+	if _, err := client.ClustersV3Api.ClustersV3updateServers(ctx, id).V3UpdateClusterServersRequest(*couchbasecapella.NewV3UpdateClusterServersRequest(servers)).Execute(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	status, _, err := client.ClustersV3Api.ClustersV3status(ctx, id).Execute()
+	if err != nil || status.Status != couchbasecapella.V3CLUSTERSTATUS_SCALING {
+		t.Fatalf("expected the cluster to be scaling, got %+v: %v", status, err)
+	}
+	if _, err := client.ClustersV3Api.ClustersV3delete(ctx, id).Execute(); err == nil {
+		t.Fatalf("expected a scaling cluster not to be deleted")
+	}
+	elapse(s.ScaleDuration)
+
+	if _, err := client.ClustersV3Api.ClustersV3delete(ctx, id).Execute(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	elapse(s.DestroyDuration)
+	if _, r, err := client.ClustersV3Api.ClustersV3status(ctx, id).Execute(); err == nil || r.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected a 404 response, got %v", err)
+	}
+}
+
+func TestServer_buckets(t *testing.T) {
+	s, client, ctx, _ := newTestServer(t)
+	clusterID := s.AddVpcCluster(s.AddProject("project"), s.AddCloud("aws", "us-east-1", "10.0.0.0/16"), "cluster")
+
+	spec := couchbasecapella.NewCouchbaseBucketSpec("bucket", 128)
+	spec.SetConflictResolution(couchbasecapella.CONFLICTRESOLUTION_LWW)
+	if _, _, err := client.ClustersApi.ClustersCreateBucket(ctx, clusterID).CouchbaseBucketSpec(*spec).Execute(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, r, err := client.ClustersApi.ClustersCreateBucket(ctx, clusterID).CouchbaseBucketSpec(*spec).Execute(); err == nil || r.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("expected a duplicate bucket to be rejected, got %v", err)
+	}
+	small := couchbasecapella.NewCouchbaseBucketSpec("small", 64)
+	if _, r, err := client.ClustersApi.ClustersCreateBucket(ctx, clusterID).CouchbaseBucketSpec(*small).Execute(); err == nil || r.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("expected a bucket below the minimum memory quota to be rejected, got %v", err)
+	}
+
+	buckets, _, err := client.ClustersApi.ClustersListBuckets(ctx, clusterID).Execute()
+	if err != nil || len(buckets) != 1 || buckets[0].ConflictResolution != couchbasecapella.CONFLICTRESOLUTION_LWW || buckets[0].Replicas != 1 {
+		t.Fatalf("expected the created bucket, got %+v: %v", buckets, err)
+	}
+
+	if _, err := client.ClustersApi.ClustersDeleteBucket(ctx, clusterID).DeleteBucketRequest(*couchbasecapella.NewDeleteBucketRequest("bucket")).Execute(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	buckets, _, err = client.ClustersApi.ClustersListBuckets(ctx, clusterID).Execute()
+	if err != nil || len(buckets) != 0 {
+		t.Fatalf("expected no buckets, got %+v: %v", buckets, err)
+	}
+}
+
+func TestServer_users(t *testing.T) {
+	s, client, ctx, _ := newTestServer(t)
+	clusterID := s.AddVpcCluster(s.AddProject("project"), s.AddCloud("aws", "us-east-1", "10.0.0.0/16"), "cluster")
+	s.AddBucket(clusterID, "bucket", 128)
+
+	request := couchbasecapella.NewCreateDatabaseUserRequest("user", "Password123!")
+	request.SetBuckets([]couchbasecapella.BucketRole{{BucketName: "bucket", BucketAccess: []couchbasecapella.BucketRoleTypes{couchbasecapella.BUCKETROLETYPES_READER}}})
+	if _, err := client.ClustersApi.ClustersCreateUser(ctx, clusterID).CreateDatabaseUserRequest(*request).Execute(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if r, err := client.ClustersApi.ClustersCreateUser(ctx, clusterID).CreateDatabaseUserRequest(*request).Execute(); err == nil || r.StatusCode != http.StatusConflict {
+		t.Fatalf("expected a duplicate user to be rejected, got %v", err)
+	}
+	weak := couchbasecapella.NewCreateDatabaseUserRequest("weak", "password")
+	weak.SetAllBucketsAccess(couchbasecapella.BUCKETROLETYPES_READER)
+	if r, err := client.ClustersApi.ClustersCreateUser(ctx, clusterID).CreateDatabaseUserRequest(*weak).Execute(); err == nil || r.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("expected a weak password to be rejected, got %v", err)
+	}
+
+	update := couchbasecapella.NewUpdateDatabaseUserRequest()
+	update.SetAllBucketsAccess(couchbasecapella.BUCKETROLETYPES_WRITER)
+	if _, err := client.ClustersApi.ClustersUpdateUser(ctx, clusterID, "user").UpdateDatabaseUserRequest(*update).Execute(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	users, _, err := client.ClustersApi.ClustersListUsers(ctx, clusterID).Execute()
+	if err != nil || len(users) != 1 || len(users[0].Access) != 0 {
+		t.Fatalf("expected the updated user, got %+v: %v", users, err)
+	}
+
+	if _, err := client.ClustersApi.ClustersDeleteUser(ctx, clusterID, "user").Execute(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if r, err := client.ClustersApi.ClustersDeleteUser(ctx, clusterID, "user").Execute(); err == nil || r.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected a 404 response, got %v", err)
+	}
+}
@@ -0,0 +1,383 @@
+// Couchbase, Inc. licenses this to you under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at https://www.apache.org/licenses/LICENSE-2.0.
+
+// Unless required by applicable law or agreed to in writing, software distributed under the License
+// is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and limitations under the License.
+
+package capellamock
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type user struct {
+	ID               string
+	Username         string
+	Password         string
+	Access           []bucketRole
+	AllBucketsAccess string
+
+	// visibleAt is when the user appears in the list of users of its cluster.
+	visibleAt time.Time
+}
+
+type bucketRole struct {
+	BucketName   string   `json:"bucketName"`
+	BucketAccess []string `json:"bucketAccess"`
+}
+
+// userRequest is the request body of the v2 database user endpoints.
+type userRequest struct {
+	Username         string        `json:"username"`
+	Password         *string       `json:"password"`
+	Buckets          *[]bucketRole `json:"buckets"`
+	AllBucketsAccess *string       `json:"allBucketsAccess"`
+}
+
+// validPassword reports whether a password is at least 8 characters long and
+// mixes upper case letters, lower case letters, digits and special characters.
+func validPassword(password string) bool {
+	var upper, lower, digit, special bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			special = true
+		}
+	}
+	return len(password) >= 8 && upper && lower && digit && special
+}
+
+func validRole(role string) bool {
+	return role == "data_reader" || role == "data_writer"
+}
+
+// validateAccess returns an error message if the roles of a user are invalid.
+func (c *cluster) validateAccess(access []bucketRole, allBucketsAccess string) string {
+	if len(access) == 0 && allBucketsAccess == "" {
+		return "either buckets or allBucketsAccess must be set"
+	}
+	if len(access) > 0 && allBucketsAccess != "" {
+		return "only one of buckets or allBucketsAccess can be set"
+	}
+	if allBucketsAccess != "" && !validRole(allBucketsAccess) {
+		return fmt.Sprintf("%s is not a valid role", allBucketsAccess)
+	}
+	for _, role := range access {
+		if _, ok := c.buckets[role.BucketName]; !ok {
+			return fmt.Sprintf("bucket %s not found", role.BucketName)
+		}
+		for _, access := range role.BucketAccess {
+			if !validRole(access) {
+				return fmt.Sprintf("%s is not a valid role", access)
+			}
+		}
+	}
+	return ""
+}
+
+// addUser adds a user to the cluster after validating it, returning the
+// status code and message of the error if it is invalid.
+func (c *cluster) addUser(u *user) (int, string) {
+	if strings.TrimSpace(u.Username) == "" {
+		return http.StatusUnprocessableEntity, "username must be set"
+	}
+	if _, ok := c.users[u.Username]; ok {
+		return http.StatusConflict, fmt.Sprintf("user %s already exists", u.Username)
+	}
+	if !validPassword(u.Password) {
+		return http.StatusUnprocessableEntity, "password must be at least 8 characters long and contain upper case letters, lower case letters, digits and special characters"
+	}
+	if message := c.validateAccess(u.Access, u.AllBucketsAccess); message != "" {
+		return http.StatusUnprocessableEntity, message
+	}
+
+	u.ID = newID()
+	c.users[u.Username] = u
+	return 0, ""
+}
+
+// visibleUsers returns the users of a cluster that appear in its list of users, by username.
+func (s *Server) visibleUsers(c *cluster) []*user {
+	now := s.now()
+	users := make([]*user, 0, len(c.users))
+	for _, u := range c.users {
+		if !now.Before(u.visibleAt) {
+			users = append(users, u)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	return users
+}
+
+// updateUser changes the password and roles of a user after validating them,
+// returning the status code and message of the error if they are invalid. The
+// roles of a user are replaced as a whole.
+func (c *cluster) updateUser(u *user, password *string, buckets *[]bucketRole, allBucketsAccess *string) (int, string) {
+	updated := *u
+	if password != nil {
+		if !validPassword(*password) {
+			return http.StatusUnprocessableEntity, "password must be at least 8 characters long and contain upper case letters, lower case letters, digits and special characters"
+		}
+		updated.Password = *password
+	}
+	if buckets != nil {
+		updated.Access, updated.AllBucketsAccess = *buckets, ""
+	} else if allBucketsAccess != nil {
+		updated.Access, updated.AllBucketsAccess = nil, *allBucketsAccess
+	}
+	if message := c.validateAccess(updated.Access, updated.AllBucketsAccess); message != "" {
+		return http.StatusUnprocessableEntity, message
+	}
+
+	*u = updated
+	return 0, ""
+}
+
+// userByID returns the user of a cluster with the given ID.
+func (c *cluster) userByID(id string) (*user, bool) {
+	for _, u := range c.users {
+		if u.ID == id {
+			return u, true
+		}
+	}
+	return nil, false
+}
+
+func (s *Server) listUsers(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.readyVpcCluster(w, r, params[0])
+	if !ok {
+		return
+	}
+
+	users := s.visibleUsers(c)
+	items := make([]map[string]interface{}, 0, len(users))
+	for _, u := range users {
+		access := u.Access
+		if access == nil {
+			access = []bucketRole{}
+		}
+		item := map[string]interface{}{
+			"userId":   u.ID,
+			"username": u.Username,
+			"access":   access,
+		}
+		if u.AllBucketsAccess != "" {
+			item["allBucketsAccess"] = u.AllBucketsAccess
+		}
+		items = append(items, item)
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (s *Server) createUser(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.readyVpcCluster(w, r, params[0])
+	if !ok {
+		return
+	}
+	var request userRequest
+	if !decodeBody(w, r, &request) {
+		return
+	}
+
+	u := &user{Username: request.Username, visibleAt: s.now().Add(s.ListDelay)}
+	if request.Password != nil {
+		u.Password = *request.Password
+	}
+	if request.Buckets != nil {
+		u.Access = *request.Buckets
+	}
+	if request.AllBucketsAccess != nil {
+		u.AllBucketsAccess = *request.AllBucketsAccess
+	}
+	if status, message := c.addUser(u); status != 0 {
+		writeError(w, status, errorType(status), message)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) updateUser(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.readyVpcCluster(w, r, params[0])
+	if !ok {
+		return
+	}
+	var request userRequest
+	if !decodeBody(w, r, &request) {
+		return
+	}
+
+	u, ok := c.users[params[1]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NotFound", fmt.Sprintf("user %s not found", params[1]))
+		return
+	}
+	if status, message := c.updateUser(u, request.Password, request.Buckets, request.AllBucketsAccess); status != 0 {
+		writeError(w, status, errorType(status), message)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteUser(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.readyVpcCluster(w, r, params[0])
+	if !ok {
+		return
+	}
+
+	if _, ok := c.users[params[1]]; !ok {
+		writeError(w, http.StatusNotFound, "NotFound", fmt.Sprintf("user %s not found", params[1]))
+		return
+	}
+	delete(c.users, params[1])
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// hostedUserBucket is the access of a user to a bucket in the v3 user endpoints,
+// which grants a single role. A user with several roles on a bucket has an entry
+// for each of them.
+type hostedUserBucket struct {
+	Name   string `json:"name"`
+	Scope  string `json:"scope"`
+	Access string `json:"access"`
+}
+
+// hostedUserRequest is the request body of the v3 user endpoints.
+type hostedUserRequest struct {
+	Username         string              `json:"username"`
+	Password         *string             `json:"password"`
+	AllBucketsAccess *string             `json:"allBucketsAccess"`
+	Buckets          *[]hostedUserBucket `json:"buckets"`
+}
+
+// access returns the roles of the request on single buckets, or nil if it doesn't set them.
+func (request hostedUserRequest) access() *[]bucketRole {
+	if request.Buckets == nil {
+		return nil
+	}
+	access := make([]bucketRole, 0, len(*request.Buckets))
+	roles := make(map[string]int)
+	for _, b := range *request.Buckets {
+		i, ok := roles[b.Name]
+		if !ok {
+			i = len(access)
+			roles[b.Name] = i
+			access = append(access, bucketRole{BucketName: b.Name})
+		}
+		access[i].BucketAccess = append(access[i].BucketAccess, b.Access)
+	}
+	return &access
+}
+
+// allBucketsAccess returns the role of the request on every bucket, or nil if it doesn't set one.
+func (request hostedUserRequest) allBucketsAccess() *string {
+	if request.AllBucketsAccess == nil || *request.AllBucketsAccess == "" {
+		return nil
+	}
+	return request.AllBucketsAccess
+}
+
+func (s *Server) listHostedUsers(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.readyHostedCluster(w, r, params[0])
+	if !ok {
+		return
+	}
+
+	users := s.visibleUsers(c)
+	start, end, page := paginate(r, len(users))
+	items := make([]map[string]interface{}, 0, end-start)
+	for _, u := range users[start:end] {
+		buckets := make([]hostedUserBucket, 0, len(u.Access))
+		for _, role := range u.Access {
+			for _, access := range role.BucketAccess {
+				buckets = append(buckets, hostedUserBucket{Name: role.BucketName, Scope: "*", Access: access})
+			}
+		}
+		item := map[string]interface{}{
+			"id":       u.ID,
+			"username": u.Username,
+			"buckets":  buckets,
+		}
+		if u.AllBucketsAccess != "" {
+			item["allBucketsAccess"] = u.AllBucketsAccess
+		}
+		items = append(items, item)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"cursor": page, "data": items})
+}
+
+func (s *Server) createHostedUser(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.readyHostedCluster(w, r, params[0])
+	if !ok {
+		return
+	}
+	var request hostedUserRequest
+	if !decodeBody(w, r, &request) {
+		return
+	}
+
+	u := &user{Username: request.Username, visibleAt: s.now().Add(s.ListDelay)}
+	if request.Password != nil {
+		u.Password = *request.Password
+	}
+	if access := request.access(); access != nil {
+		u.Access = *access
+	}
+	if allBucketsAccess := request.allBucketsAccess(); allBucketsAccess != nil {
+		u.AllBucketsAccess = *allBucketsAccess
+	}
+	if status, message := c.addUser(u); status != 0 {
+		writeV3Error(w, status, "", message)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) updateHostedUser(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.readyHostedCluster(w, r, params[0])
+	if !ok {
+		return
+	}
+	var request hostedUserRequest
+	if !decodeBody(w, r, &request) {
+		return
+	}
+
+	u, ok := c.userByID(params[1])
+	if !ok {
+		writeV3Error(w, http.StatusNotFound, "", fmt.Sprintf("user %s not found", params[1]))
+		return
+	}
+	if status, message := c.updateUser(u, request.Password, request.access(), request.allBucketsAccess()); status != 0 {
+		writeV3Error(w, status, "", message)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteHostedUser(w http.ResponseWriter, r *http.Request, params []string) {
+	c, ok := s.readyHostedCluster(w, r, params[0])
+	if !ok {
+		return
+	}
+
+	u, ok := c.userByID(params[1])
+	if !ok {
+		writeV3Error(w, http.StatusNotFound, "", fmt.Sprintf("user %s not found", params[1]))
+		return
+	}
+	delete(c.users, u.Username)
+	w.WriteHeader(http.StatusNoContent)
+}
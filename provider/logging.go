@@ -0,0 +1,159 @@
+// Couchbase, Inc. licenses this to you under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at https://www.apache.org/licenses/LICENSE-2.0.
+
+// Unless required by applicable law or agreed to in writing, software distributed under the License
+// is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and limitations under the License.
+
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	// logSubsystem is the tflog subsystem the Capella API requests are logged to.
+	// Its level can be set on its own with TF_LOG_PROVIDER_COUCHBASECAPELLA_API.
+	logSubsystem      = "api"
+	logSubsystemLevel = "TF_LOG_PROVIDER_COUCHBASECAPELLA"
+
+	redacted = "<redacted>"
+)
+
+// sensitiveHeaders are the headers whose value is never logged.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+}
+
+// sensitiveFields are the JSON body fields, compared case-insensitively,
+// whose value is never logged.
+var sensitiveFields = map[string]bool{
+	"password":   true,
+	"secret":     true,
+	"secretkey":  true,
+	"secret_key": true,
+}
+
+// loggingTransport is an http.RoundTripper that logs every request sent to the
+// Capella API and its response. The method, path, status, latency and request ID
+// are logged at DEBUG level, while the redacted bodies are only logged at TRACE level.
+type loggingTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	fields := map[string]interface{}{
+		"method": req.Method,
+		"path":   req.URL.Path,
+	}
+
+	tflog.SubsystemDebug(ctx, logSubsystem, "Sending Capella API request", fields, map[string]interface{}{
+		"headers": redactHeaders(req.Header),
+	})
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			tflog.SubsystemTrace(ctx, logSubsystem, "Capella API request body", fields, map[string]interface{}{
+				"body": redactBody(readBody(body)),
+			})
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	fields["latency_ms"] = time.Since(start).Milliseconds()
+	if err != nil {
+		tflog.SubsystemDebug(ctx, logSubsystem, "Capella API request failed", fields, map[string]interface{}{
+			"error": err.Error(),
+		})
+		return resp, err
+	}
+
+	fields["status"] = resp.StatusCode
+	fields["request_id"] = resp.Header.Get("X-Request-Id")
+	tflog.SubsystemDebug(ctx, logSubsystem, "Received Capella API response", fields)
+
+	if resp.Body != nil {
+		// The body is buffered so it can still be decoded by the API client.
+		body := readBody(resp.Body)
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		if len(body) > 0 {
+			tflog.SubsystemTrace(ctx, logSubsystem, "Capella API response body", fields, map[string]interface{}{
+				"body": redactBody(body),
+			})
+		}
+	}
+
+	return resp, nil
+}
+
+// readBody reads and closes a request or response body.
+func readBody(body io.ReadCloser) []byte {
+	defer body.Close()
+	b, _ := io.ReadAll(body)
+	return b
+}
+
+// redactHeaders returns the headers as a map suitable for logging, with the
+// value of the headers carrying credentials redacted.
+func redactHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for name, values := range header {
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			headers[name] = redacted
+			continue
+		}
+		headers[name] = strings.Join(values, ", ")
+	}
+	return headers
+}
+
+// redactBody returns a JSON body as a string suitable for logging, with the
+// value of the fields carrying passwords and secret keys redacted. The Capella API
+// only exchanges JSON, so a body that can't be decoded is dropped rather than
+// logged unredacted.
+func redactBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return redacted
+	}
+	var redactedJSON bytes.Buffer
+	encoder := json.NewEncoder(&redactedJSON)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(redactValue(value)); err != nil {
+		return redacted
+	}
+	return strings.TrimSpace(redactedJSON.String())
+}
+
+// redactValue walks a decoded JSON value and redacts the sensitive fields it contains.
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if sensitiveFields[strings.ToLower(key)] {
+				v[key] = redacted
+				continue
+			}
+			v[key] = redactValue(field)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
@@ -0,0 +1,76 @@
+// Couchbase, Inc. licenses this to you under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at https://www.apache.org/licenses/LICENSE-2.0.
+
+// Unless required by applicable law or agreed to in writing, software distributed under the License
+// is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	couchbasecapella "github.com/couchbasecloud/couchbase-capella-api-go-client"
+	"github.com/hashicorp/terraform-plugin-log/tfsdklog"
+)
+
+// Test to see if API calls are logged with their bodies and without credentials
+func TestLoggingTransport(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "provider.log")
+	t.Setenv("TF_LOG", "TRACE")
+	t.Setenv("TF_LOG_PATH", logFile)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-42")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client, err := (&Config{AccessKey: "access", SecretKey: "super-secret-key", APIURL: server.URL}).NewClient()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ctx := tfsdklog.RegisterTestSink(context.Background(), t)
+	ctx = tfsdklog.NewRootProviderLogger(ctx)
+	auth := client.getAuth(ctx)
+
+	request := *couchbasecapella.NewCreateDatabaseUserRequest("user", "Sup3r-Secret-Passw0rd!")
+	if _, err := client.ClustersApi.ClustersCreateUser(auth, "c0ffee00-0000-4000-8000-000000000000").CreateDatabaseUserRequest(request).Execute(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	b, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	logs := string(b)
+
+	for _, want := range []string{"Received Capella API response", "POST", "/v2/clusters/c0ffee00-0000-4000-8000-000000000000/users", "201", "req-42", "latency_ms", "username", "<redacted>"} {
+		if !strings.Contains(logs, want) {
+			t.Fatalf("expected the logs to contain %q, got:\n%s", want, logs)
+		}
+	}
+	for _, secret := range []string{"Sup3r-Secret-Passw0rd!", "access:"} {
+		if strings.Contains(logs, secret) {
+			t.Fatalf("expected %q to be redacted, got:\n%s", secret, logs)
+		}
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	body := redactBody([]byte(`{"username":"user","password":"p","buckets":[{"name":"b","secretKey":"s"}]}`))
+	if body != `{"buckets":[{"name":"b","secretKey":"<redacted>"}],"password":"<redacted>","username":"user"}` {
+		t.Fatalf("unexpected body %s", body)
+	}
+	if body := redactBody([]byte("password=p")); body != redacted {
+		t.Fatalf("expected a body that isn't JSON to be dropped, got %s", body)
+	}
+}
@@ -10,10 +10,11 @@ package provider
 
 import (
 	"context"
+	"time"
 
-	couchbasecapella "github.com/couchbasecloud/couchbase-capella-api-go-client"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func init() {
@@ -25,17 +26,76 @@ func Provider() *schema.Provider {
 		Schema: map[string]*schema.Schema{
 			"access_key": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("CBC_ACCESS_KEY", nil),
 				Description: "Couchbase Capella API Access Key",
 			},
 			"secret_key": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("CBC_SECRET_KEY", nil),
 				Description: "Couchbase Capella API Secret Key",
 				Sensitive:   true,
 			},
+			"profile": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CBC_PROFILE", nil),
+				Description: "Profile of the shared credentials file to read the API keys from when they aren't set on the provider or in the environment",
+			},
+			"shared_credentials_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CBC_SHARED_CREDENTIALS_FILE", DefaultCredentialsFile),
+				Description: "Path to the shared credentials file",
+			},
+			"project_id": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CBC_PROJECT_ID", nil),
+				Description:  "ID of the Project that clusters are created in when they don't set their own project_id",
+				ValidateFunc: validation.IsUUID,
+			},
+			"api_url": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CBC_API_URL", DefaultAPIURL),
+				Description:  "Base URL of the Couchbase Capella API",
+				ValidateFunc: validation.IsURLWithHTTPorHTTPS,
+			},
+			"request_timeout": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CBC_REQUEST_TIMEOUT", 60),
+				Description:  "Timeout in seconds for a request to the Couchbase Capella API, including retries",
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"max_retries": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CBC_MAX_RETRIES", defaultMaxRetries),
+				Description:  "Maximum number of times an idempotent request is retried after being throttled or failing with a transient error",
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"proxy_url": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CBC_PROXY_URL", nil),
+				Description:  "URL of the HTTP or HTTPS proxy used to reach the Couchbase Capella API. Defaults to the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables",
+				ValidateFunc: validation.IsURLWithHTTPorHTTPS,
+			},
+			"ca_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CBC_CA_FILE", nil),
+				Description: "Path to a PEM encoded CA bundle trusted in addition to the system certificates",
+			},
+			"insecure_skip_verify": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CBC_INSECURE_SKIP_VERIFY", false),
+				Description: "Skip verification of the Couchbase Capella API TLS certificate. Only use this for testing",
+			},
 		},
 
 		DataSourcesMap: map[string]*schema.Resource{},
@@ -51,10 +111,27 @@ func Provider() *schema.Provider {
 	}
 }
 
-// TODO: create a client with access/secret keys
-// providerConfigure is responsible for initializing the client
+// providerConfigure is responsible for initializing the client with the
+// credentials and connection settings set on the provider block. The CRUD
+// functions receive it as a capellaClient.
 func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
-	configuration := couchbasecapella.NewConfiguration()
-	apiClient := couchbasecapella.NewAPIClient(configuration)
-	return apiClient, nil
+	config := Config{
+		AccessKey:          d.Get("access_key").(string),
+		SecretKey:          d.Get("secret_key").(string),
+		ProjectID:          d.Get("project_id").(string),
+		Profile:            d.Get("profile").(string),
+		CredentialsFile:    d.Get("shared_credentials_file").(string),
+		APIURL:             d.Get("api_url").(string),
+		RequestTimeout:     time.Duration(d.Get("request_timeout").(int)) * time.Second,
+		ProxyURL:           d.Get("proxy_url").(string),
+		CAFile:             d.Get("ca_file").(string),
+		InsecureSkipVerify: d.Get("insecure_skip_verify").(bool),
+		MaxRetries:         d.Get("max_retries").(int),
+	}
+
+	client, err := config.NewClient()
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+	return client, nil
 }
@@ -9,10 +9,18 @@
 package provider
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/couchbasecloud/terraform-provider-couchbasecapella/provider/internal/capellamock"
+	ctyjson "github.com/hashicorp/go-cty/cty/json"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 var testAccProviders map[string]*schema.Provider
@@ -25,6 +33,159 @@ func init() {
 	}
 }
 
+// TestMain runs the acceptance tests against the mock Capella API when CBC_ACC_MOCK
+// is set, so they need neither Capella credentials nor network access.
+func TestMain(m *testing.M) {
+	if os.Getenv("TF_ACC") == "" || os.Getenv("CBC_ACC_MOCK") == "" {
+		os.Exit(m.Run())
+	}
+	os.Exit(testAccRunWithMockServer(m))
+}
+
+// testAccRunWithMockServer is responsible for seeding a mock Capella API with the
+// resources the acceptance tests expect to exist, pointing the provider at it and
+// running the tests.
+func testAccRunWithMockServer(m *testing.M) int {
+	server := capellamock.NewServer()
+	defer server.Close()
+
+	projectId := server.AddProject("testacc-project")
+	awsCloudId := server.AddCloud("aws", "us-east-1", "10.0.0.0/16")
+	azureCloudId := server.AddCloud("azure", "eastus", "10.1.0.0/16")
+	gcpCloudId := server.AddCloud("gcp", "us-east1", "10.2.0.0/16")
+	clusterId := server.AddVpcCluster(projectId, awsCloudId, "testacc-cluster")
+	server.AddBucket(clusterId, "testacc-bucket", 128)
+
+	env := map[string]string{
+		"CBC_API_URL":        server.URL,
+		"CBC_ACCESS_KEY":     server.AccessKey,
+		"CBC_SECRET_KEY":     server.SecretKey,
+		"CBC_AWS_CLOUD_ID":   awsCloudId,
+		"CBC_AZURE_CLOUD_ID": azureCloudId,
+		"CBC_GCP_CLOUD_ID":   gcpCloudId,
+		"CBC_PROJECT_ID":     projectId,
+		"CBC_CLUSTER_ID":     clusterId,
+		"CBC_CLUSTER_CIDR":   "10.0.16.0/20",
+		"CBC_BUCKET_NAME":    "testacc-bucket",
+	}
+	for key, value := range env {
+		if err := os.Setenv(key, value); err != nil {
+			panic(err)
+		}
+	}
+
+	// The mock clusters only take a second to change status
+	hostedClusterPollDelay, vpcClusterPollDelay = time.Second, time.Second
+	clusterPollMinTimeout, vpcClusterDeletePollMinTimeout = 200*time.Millisecond, 200*time.Millisecond
+	listPollInterval = 200 * time.Millisecond
+
+	return m.Run()
+}
+
+// newTestMockClient is responsible for starting a mock Capella API for a unit test
+// along with a client for it. The clusters of the mock change status quickly, and
+// the poll intervals and retry waits of the provider are shortened to match until
+// the test ends.
+func newTestMockClient(t *testing.T) (*capellamock.Server, *Client) {
+	server := capellamock.NewServer()
+	t.Cleanup(server.Close)
+	server.DeployDuration = 100 * time.Millisecond
+	server.ScaleDuration = 100 * time.Millisecond
+	server.DestroyDuration = 100 * time.Millisecond
+
+	client, err := (&Config{
+		AccessKey:    server.AccessKey,
+		SecretKey:    server.SecretKey,
+		APIURL:       server.URL,
+		MaxRetries:   defaultMaxRetries,
+		RetryMinWait: 10 * time.Millisecond,
+		RetryMaxWait: 50 * time.Millisecond,
+	}).NewClient()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	shortenPollIntervals(t)
+
+	return server, client
+}
+
+// shortenPollIntervals is responsible for shortening the delays between the polls
+// of the provider until the test ends.
+func shortenPollIntervals(t *testing.T) {
+	pollDelays := []*time.Duration{&hostedClusterPollDelay, &vpcClusterPollDelay, &clusterPollMinTimeout, &vpcClusterDeletePollMinTimeout, &listPollInterval}
+	saved := make([]time.Duration, len(pollDelays))
+	for i, delay := range pollDelays {
+		saved[i] = *delay
+		*delay = 20 * time.Millisecond
+	}
+	t.Cleanup(func() {
+		for i, delay := range pollDelays {
+			*delay = saved[i]
+		}
+	})
+}
+
+// testAccCheckResourceIdUnchanged is responsible for checking that a resource keeps
+// its id across the steps of an acceptance test, i.e. that it is updated in place
+// rather than replaced. The first check records the id.
+func testAccCheckResourceIdUnchanged(resourceName string, id *string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[resourceName]
+		if !ok {
+			return fmt.Errorf("not found: %s", resourceName)
+		}
+		if *id == "" {
+			*id = rs.Primary.ID
+			return nil
+		}
+		if rs.Primary.ID != *id {
+			return fmt.Errorf("%s was replaced: its id changed from %s to %s", resourceName, *id, rs.Primary.ID)
+		}
+		return nil
+	}
+}
+
+// testResourceDataUpdate is responsible for building the resource data of an update
+// from the raw configuration a resource was applied with to its new raw configuration.
+func testResourceDataUpdate(t *testing.T, r *schema.Resource, id string, old, new map[string]interface{}, meta interface{}) *schema.ResourceData {
+	state, diff, err := testResourceDiff(t, r, id, old, new, meta)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	d, err := schema.InternalMap(r.Schema).Data(state, diff)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return d
+}
+
+// testResourceDiff is responsible for planning the change of a resource from the raw
+// configuration it was applied with to its new raw configuration. Like Terraform, it
+// passes the new configuration as the raw config that GetRawConfig returns. A
+// resource that doesn't exist yet is planned from a nil old raw configuration.
+func testResourceDiff(t *testing.T, r *schema.Resource, id string, old, new map[string]interface{}, meta interface{}) (*terraform.InstanceState, *terraform.InstanceDiff, error) {
+	state := &terraform.InstanceState{}
+	if old != nil {
+		current := schema.TestResourceDataRaw(t, r.Schema, old)
+		current.SetId(id)
+		state = current.State()
+	}
+
+	b, err := json.Marshal(new)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	config, err := ctyjson.Unmarshal(b, r.CoreConfigSchema().ImpliedType())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	state.RawConfig = config
+
+	diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigShimmed(config, r.CoreConfigSchema()), meta)
+	return state, diff, err
+}
+
 func TestProvider(t *testing.T) {
 	if err := Provider().InternalValidate(); err != nil {
 		t.Fatalf("err: %s", err)
@@ -35,6 +196,67 @@ func TestProvider_impl(t *testing.T) {
 	var _ *schema.Provider = Provider()
 }
 
+// Test to see if aliased providers keep the credentials of their own provider block
+func TestProvider_configureCredentials(t *testing.T) {
+	sandbox := Provider()
+	production := Provider()
+
+	diags := sandbox.Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"access_key": "sandbox-access",
+		"secret_key": "sandbox-secret",
+	}))
+	if diags.HasError() {
+		t.Fatalf("err: %v", diags)
+	}
+	diags = production.Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"access_key": "production-access",
+		"secret_key": "production-secret",
+	}))
+	if diags.HasError() {
+		t.Fatalf("err: %v", diags)
+	}
+
+	sandboxClient := sandbox.Meta().(*Client)
+	productionClient := production.Meta().(*Client)
+	if sandboxClient.accessKey != "sandbox-access" || sandboxClient.secretKey != "sandbox-secret" {
+		t.Fatalf("sandbox provider configured with wrong credentials: %s", sandboxClient.accessKey)
+	}
+	if productionClient.accessKey != "production-access" || productionClient.secretKey != "production-secret" {
+		t.Fatalf("production provider configured with wrong credentials: %s", productionClient.accessKey)
+	}
+}
+
+// Test to see if clusters fall back to the project_id set on the provider
+func TestProvider_defaultProjectID(t *testing.T) {
+	config := map[string]interface{}{
+		"name":     "cluster",
+		"cloud_id": "c0ffee00-0000-4000-8000-000000000000",
+	}
+
+	client := &Client{projectID: "c0ffee00-0000-4000-8000-000000000001"}
+	diff, err := resourceCouchbaseCapellaVpcCluster().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(config), client)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if got := diff.Attributes["project_id"].New; got != client.projectID {
+		t.Fatalf("expected the provider project_id, got %q", got)
+	}
+
+	config["project_id"] = "c0ffee00-0000-4000-8000-000000000002"
+	diff, err = resourceCouchbaseCapellaVpcCluster().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(config), client)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if got := diff.Attributes["project_id"].New; got != "c0ffee00-0000-4000-8000-000000000002" {
+		t.Fatalf("expected the resource project_id to take precedence, got %q", got)
+	}
+
+	delete(config, "project_id")
+	if _, err := resourceCouchbaseCapellaVpcCluster().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(config), &Client{}); err == nil {
+		t.Fatalf("expected an error when project_id is set neither on the resource nor on the provider")
+	}
+}
+
 func testAccPreCheck(t *testing.T) {
 	if err := os.Getenv("CBC_ACCESS_KEY"); err == "" {
 		t.Fatal("CBC_ACCESS_KEY must be set for acceptance tests")
@@ -48,6 +270,9 @@ func testAccPreCheck(t *testing.T) {
 	if err := os.Getenv("CBC_AZURE_CLOUD_ID"); err == "" {
 		t.Fatal("CBC_AZURE_CLOUD_ID must be set for acceptance tests")
 	}
+	if err := os.Getenv("CBC_GCP_CLOUD_ID"); err == "" {
+		t.Fatal("CBC_GCP_CLOUD_ID must be set for acceptance tests")
+	}
 	if err := os.Getenv("CBC_PROJECT_ID"); err == "" {
 		t.Fatal("CBC_PROJECT_ID must be set for acceptance tests")
 	}
@@ -11,10 +11,15 @@ package provider
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
 	couchbasecapella "github.com/couchbasecloud/couchbase-capella-api-go-client"
+	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
@@ -56,91 +61,125 @@ func resourceCouchbaseCapellaBucket() *schema.Resource {
 				ForceNew:     true,
 				ValidateFunc: validateConflictResolution,
 			},
+			"replicas": {
+				Description:  "Number of replicas of the data of the bucket",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      1,
+				ValidateFunc: validateBucketReplicas,
+			},
+			"durability_level": {
+				Description:  "Minimum durability level of the writes to the bucket",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      bucketDurabilityNone,
+				ValidateFunc: validateBucketDurabilityLevel,
+			},
+			"eviction_policy": {
+				Description:  "Eviction policy of the bucket, which defaults to valueOnly for couchbase buckets and noEviction for ephemeral buckets",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateBucketEvictionPolicy,
+			},
+			"max_ttl": {
+				Description:  "Maximum time to live of the documents of the bucket in seconds, 0 for documents that don't expire",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validateBucketMaxTTL,
+			},
+			"storage_backend": {
+				Description:  "Storage backend of a couchbase bucket, which defaults to couchstore",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateBucketStorageBackend,
+			},
+			"bucket_type": {
+				Description:  "Type of the bucket",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      bucketTypeCouchbase,
+				ForceNew:     true,
+				ValidateFunc: validateBucketType,
+			},
+			"flush_enabled": {
+				Description: "Whether the documents of the bucket can be flushed",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+		},
+		CustomizeDiff: customdiff.All(
+			customizeDiffBucketType,
+			customizeDiffBucketUpdate,
+		),
+		Timeouts: &schema.ResourceTimeout{
+			Update: schema.DefaultTimeout(10 * time.Minute),
 		},
 	}
 }
 
 // resourceCouchbaseCapellaBucketCreate is responsible for creating a
-// bucket in a Couchbase Capella VPC Cluster using the Terraform resource data.
+// bucket in a Couchbase Capella Cluster using the Terraform resource data.
 func resourceCouchbaseCapellaBucketCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*couchbasecapella.APIClient)
-	auth := getAuth(ctx)
+	client := meta.(capellaClient)
 
 	clusterId := d.Get("cluster_id").(string)
-
-	// Check if the Cluster is inVPC to create the bucket
-	// Managing buckets is not available for hosted clusters
-	_, _, clusterError := client.ClustersApi.ClustersShow(auth, clusterId).Execute()
-
-	if clusterError != nil {
-		// Check V3Cluster :: Need to be fixed in next versions
-		_, _, err3 := client.ClustersV3Api.ClustersV3show(auth, clusterId).Execute()
-		if err3 != nil {
-			return diag.FromErr(fmt.Errorf(ClusterProblemAccessing))
-		}
-		return diag.FromErr(fmt.Errorf(BucketHostedNotSupported))
+	kind, diags := getClusterKind(ctx, client, clusterId)
+	if diags.HasError() {
+		return diags
 	}
 
-	bucketName := d.Get("name").(string)
-	memoryQuota := int32(d.Get("memory_quota").(int))
-	conflictResolution := couchbasecapella.ConflictResolution(d.Get("conflict_resolution").(string))
-
-	couchbaseBucketSpec := couchbasecapella.NewCouchbaseBucketSpec(bucketName, memoryQuota)
-	couchbaseBucketSpec.SetConflictResolution(conflictResolution)
+	couchbaseBucketSpec := expandBucketSpec(d)
 
-	_, r, err := client.ClustersApi.ClustersCreateBucket(auth, clusterId).CouchbaseBucketSpec(*couchbaseBucketSpec).Execute()
-	if r == nil {
-		return diag.Errorf("Pointer to bucket create http.Response is nil")
-	}
+	r, err := createBucket(ctx, client, clusterId, kind, couchbaseBucketSpec)
 	if err != nil {
-		return diag.FromErr(fmt.Errorf("problem ocurred :: %v", r))
+		return manageErrors(err, r, "Create Bucket")
 	}
 
-	d.SetId(bucketName)
+	d.SetId(couchbaseBucketSpec.Name)
 
 	return resourceCouchbaseCapellaBucketRead(ctx, d, meta)
 }
 
 // resourceCouchbaseCapellaBucketRead is responsible for reading a
-// bucket in a Couchbase Capella VPC Cluster using the Terraform resource data.
+// bucket in a Couchbase Capella Cluster using the Terraform resource data.
 func resourceCouchbaseCapellaBucketRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*couchbasecapella.APIClient)
-	auth := getAuth(ctx)
+	client := meta.(capellaClient)
 
 	clusterId := d.Get("cluster_id").(string)
-
-	// Check if the Cluster is inVPC to read the bucket list
-	// Managing buckets is not available for hosted clusters
-	_, _, err := client.ClustersApi.ClustersShow(auth, clusterId).Execute()
-
-	if err != nil {
-		// Check V3Cluster :: Need to be fixed in next versions
-		_, _, err3 := client.ClustersV3Api.ClustersV3show(auth, clusterId).Execute()
-		if err3 != nil {
-			return diag.FromErr(fmt.Errorf(ClusterProblemAccessing))
-		}
-		return diag.FromErr(fmt.Errorf(BucketHostedNotSupported))
+	kind, diags := getClusterKind(ctx, client, clusterId)
+	if diags.HasError() {
+		return diags
 	}
 
 	// NOTE: There is a delay for retrieving a newly created bucket from Capella's list of buckets.
 	// This poll will check at regular intervals if the newly created bucket is in the list of buckets
 	// until the timeout period expires. If the timeout is reached, then the newly created bucket is not in the list of buckets.
 	timeout := time.NewTimer(time.Second * 180)
-	ticker := time.NewTicker(time.Second * 2)
+	ticker := time.NewTicker(listPollInterval)
+	defer timeout.Stop()
+	defer ticker.Stop()
 	for {
 		select {
+		case <-ctx.Done():
+			return diag.FromErr(ctx.Err())
 		case <-timeout.C:
 			bucketName := d.Id()
 			d.SetId("")
 			return diag.Errorf("Error 404: Failed to find the bucket %s ", bucketName)
 		case <-ticker.C:
-			buckets, _, err := client.ClustersApi.ClustersListBuckets(auth, clusterId).Execute()
+			buckets, r, err := listBuckets(ctx, client, clusterId, kind)
 			if err != nil {
-				return diag.FromErr(err)
+				return manageErrors(err, r, "Read Bucket")
 			}
 			for _, bucket := range buckets {
 				if bucket.Name == d.Id() {
-					return nil
+					return setBucket(d, bucket)
 				}
 			}
 		}
@@ -148,74 +187,267 @@ func resourceCouchbaseCapellaBucketRead(ctx context.Context, d *schema.ResourceD
 }
 
 // resourceCouchbaseCapellaBucketUpdate is responsible for updating a
-// bucket in a Couchbase Capella VPC Cluster using the Terraform resource data.
+// bucket in a Couchbase Capella Cluster using the Terraform resource data.
 func resourceCouchbaseCapellaBucketUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*couchbasecapella.APIClient)
-	auth := getAuth(ctx)
+	client := meta.(capellaClient)
 
 	clusterId := d.Get("cluster_id").(string)
+	kind, diags := getClusterKind(ctx, client, clusterId)
+	if diags.HasError() {
+		return diags
+	}
 
-	// Check if the Cluster is inVPC to update the bucket
-	// Managing buckets is not available for hosted clusters
-	_, _, err := client.ClustersApi.ClustersShow(auth, clusterId).Execute()
+	couchbaseBucketSpec := expandBucketSpec(d)
 
+	// Both kinds of clusters update a bucket by its ID rather than its name.
+	bucket, r, err := findBucket(ctx, client, clusterId, kind, couchbaseBucketSpec.Name)
 	if err != nil {
-		// Check V3Cluster :: Need to be fixed in next versions
-		_, _, err3 := client.ClustersV3Api.ClustersV3show(auth, clusterId).Execute()
-		if err3 != nil {
-			return diag.FromErr(fmt.Errorf(ClusterProblemAccessing))
-		}
-		return diag.FromErr(fmt.Errorf(BucketHostedNotSupported))
+		return manageErrors(err, r, "Update Bucket")
+	}
+	if bucket == nil {
+		return diag.Errorf("Failed to update: Bucket doesn't exist in list of buckets")
+	}
+	if kind == hostedClusterKind {
+		r, err = client.UpdateHostedBucket(ctx, clusterId, bucket.Id, couchbaseBucketSpec)
+	} else {
+		r, err = client.UpdateBucket(ctx, clusterId, bucket.Id, couchbaseBucketSpec)
 	}
-
-	bucketName := d.Get("name").(string)
-
-	// List buckets and iterate through to find bucket ID
-	buckets, _, err := client.ClustersApi.ClustersListBuckets(auth, clusterId).Execute()
 	if err != nil {
-		return diag.FromErr(err)
+		return manageErrors(err, r, "Update Bucket")
 	}
-	for _, bucket := range buckets {
-		if bucket.Name == bucketName {
-			if err := d.Set("conflict_resolution", bucket.ConflictResolution); err != nil {
-				return diag.FromErr(err)
-			}
-			if err := d.Set("memory_quota", bucket.MemoryQuota); err != nil {
-				return diag.FromErr(err)
-			}
-		}
+
+	// NOTE: There is a delay before the new settings of a bucket show in Capella's list of buckets,
+	// so reading the bucket straight away would bring back the old settings.
+	updateStateConf := &resource.StateChangeConf{
+		Pending:    []string{"updating"},
+		Target:     []string{"updated"},
+		Refresh:    bucketUpdateRefreshFunc(ctx, client, clusterId, kind, couchbaseBucketSpec),
+		Timeout:    d.Timeout(schema.TimeoutUpdate),
+		MinTimeout: listPollInterval,
 	}
-	return diag.FromErr(fmt.Errorf(BucketHostedNotSupported))
-	// return resourceCouchbaseCapellaBucketRead(ctx, d, meta)
+	if _, err := updateStateConf.WaitForStateContext(ctx); err != nil {
+		return diag.Errorf("Error waiting for bucket (%s) to be updated: %s", d.Id(), err)
+	}
+
+	return resourceCouchbaseCapellaBucketRead(ctx, d, meta)
 }
 
 // resourceCouchbaseCapellaBucketDelete is responsible for deleting a
-// bucket in a Couchbase Capella VPC Cluster using the Terraform resource data.
+// bucket in a Couchbase Capella Cluster using the Terraform resource data.
 func resourceCouchbaseCapellaBucketDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*couchbasecapella.APIClient)
-	auth := getAuth(ctx)
+	client := meta.(capellaClient)
 
 	clusterId := d.Get("cluster_id").(string)
+	kind, diags := getClusterKind(ctx, client, clusterId)
+	if diags.HasError() {
+		return diags
+	}
+	bucketName := d.Get("name").(string)
 
-	// Check if the Cluster is inVPC to delete the bucket
-	// Managing buckets is not available for hosted clusters
-	_, _, err := client.ClustersApi.ClustersShow(auth, clusterId).Execute()
-
-	if err != nil {
-		// Check V3Cluster :: Need to be fixed in next versions
-		_, _, err3 := client.ClustersV3Api.ClustersV3show(auth, clusterId).Execute()
-		if err3 != nil {
-			return diag.FromErr(fmt.Errorf(ClusterProblemAccessing))
+	if kind == hostedClusterKind {
+		// The v3 endpoints delete a bucket by its ID rather than its name.
+		bucket, r, err := findBucket(ctx, client, clusterId, kind, bucketName)
+		if err != nil {
+			return manageErrors(err, r, "Delete Bucket")
+		}
+		if bucket == nil {
+			return nil
+		}
+		r, err = client.DeleteHostedBucket(ctx, clusterId, bucket.Id)
+		if err != nil {
+			return manageErrors(err, r, "Delete Bucket")
 		}
-		return diag.FromErr(fmt.Errorf(BucketHostedNotSupported))
+		return nil
 	}
-	bucketName := d.Get("name").(string)
 
 	deleteBucketRequest := *couchbasecapella.NewDeleteBucketRequest(bucketName)
 
-	_, deleteError := client.ClustersApi.ClustersDeleteBucket(auth, clusterId).DeleteBucketRequest(deleteBucketRequest).Execute()
+	r, deleteError := client.DeleteBucket(ctx, clusterId, deleteBucketRequest)
 	if deleteError != nil {
-		return diag.FromErr(deleteError)
+		return manageErrors(deleteError, r, "Delete Bucket")
 	}
 	return nil
 }
+
+// listBuckets is responsible for listing the buckets of a cluster through the
+// endpoints of its kind.
+func listBuckets(ctx context.Context, client capellaClient, clusterId string, kind clusterKind) ([]bucketSpec, *http.Response, error) {
+	if kind == hostedClusterKind {
+		return client.ListHostedBuckets(ctx, clusterId)
+	}
+	return client.ListBuckets(ctx, clusterId)
+}
+
+// createBucket is responsible for creating a bucket in a cluster through the
+// endpoints of its kind.
+func createBucket(ctx context.Context, client capellaClient, clusterId string, kind clusterKind, spec bucketSpec) (*http.Response, error) {
+	if kind == hostedClusterKind {
+		return client.CreateHostedBucket(ctx, clusterId, spec)
+	}
+	return client.CreateBucket(ctx, clusterId, spec)
+}
+
+// findBucket is responsible for finding a bucket of a cluster by its name. It
+// returns nil when the cluster has no such bucket.
+func findBucket(ctx context.Context, client capellaClient, clusterId string, kind clusterKind, name string) (*bucketSpec, *http.Response, error) {
+	buckets, r, err := listBuckets(ctx, client, clusterId, kind)
+	if err != nil {
+		return nil, r, err
+	}
+	for i := range buckets {
+		if buckets[i].Name == name {
+			return &buckets[i], r, nil
+		}
+	}
+	return nil, r, nil
+}
+
+// bucketUpdateRefreshFunc is responsible for reading a bucket while waiting for
+// the list of buckets of its cluster to show the settings of the spec.
+func bucketUpdateRefreshFunc(ctx context.Context, client capellaClient, clusterId string, kind clusterKind, spec bucketSpec) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		bucket, _, err := findBucket(ctx, client, clusterId, kind, spec.Name)
+		if err != nil {
+			return nil, "", err
+		}
+		if bucket == nil {
+			return nil, "", fmt.Errorf("bucket %s not found", spec.Name)
+		}
+		if !bucketUpdated(*bucket, spec, kind) {
+			return bucket, "updating", nil
+		}
+		return bucket, "updated", nil
+	}
+}
+
+// bucketUpdated is responsible for checking whether a bucket has the settings of the
+// spec that can be updated in place, which is only the memory quota in a vpc cluster.
+// A durability level the Capella API doesn't report is taken as updated.
+func bucketUpdated(bucket, spec bucketSpec, kind clusterKind) bool {
+	if kind == vpcClusterKind {
+		return bucket.MemoryQuota == spec.MemoryQuota
+	}
+	return bucket.MemoryQuota == spec.MemoryQuota &&
+		bucket.Replicas == spec.Replicas &&
+		(bucket.DurabilityLevel == "" || bucket.DurabilityLevel == spec.DurabilityLevel) &&
+		bucket.MaxTTL == spec.MaxTTL &&
+		bucket.FlushEnabled == spec.FlushEnabled
+}
+
+// expandBucketSpec is responsible for converting the Terraform resource data into
+// the spec of a bucket. The eviction policy and storage backend are left to Capella
+// to default when they aren't set.
+func expandBucketSpec(d *schema.ResourceData) bucketSpec {
+	return bucketSpec{
+		Name:               d.Get("name").(string),
+		MemoryQuota:        int32(d.Get("memory_quota").(int)),
+		Replicas:           int32(d.Get("replicas").(int)),
+		ConflictResolution: d.Get("conflict_resolution").(string),
+		DurabilityLevel:    d.Get("durability_level").(string),
+		EvictionPolicy:     d.Get("eviction_policy").(string),
+		MaxTTL:             int32(d.Get("max_ttl").(int)),
+		StorageBackend:     d.Get("storage_backend").(string),
+		BucketType:         d.Get("bucket_type").(string),
+		FlushEnabled:       d.Get("flush_enabled").(bool),
+	}
+}
+
+// setBucket is responsible for setting the settings of a bucket read from the
+// Capella API in the Terraform resource data. Settings the Capella API doesn't
+// report are left as they are.
+func setBucket(d *schema.ResourceData, bucket bucketSpec) diag.Diagnostics {
+	settings := map[string]interface{}{
+		"memory_quota":  int(bucket.MemoryQuota),
+		"replicas":      int(bucket.Replicas),
+		"max_ttl":       int(bucket.MaxTTL),
+		"flush_enabled": bucket.FlushEnabled,
+	}
+	for key, value := range map[string]string{
+		"conflict_resolution": bucket.ConflictResolution,
+		"durability_level":    bucket.DurabilityLevel,
+		"eviction_policy":     bucket.EvictionPolicy,
+		"storage_backend":     bucket.StorageBackend,
+		"bucket_type":         bucket.BucketType,
+	} {
+		if value != "" {
+			settings[key] = value
+		}
+	}
+	for key, value := range settings {
+		if err := d.Set(key, value); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	return nil
+}
+
+// customizeDiffBucketType is responsible for validating the settings of a bucket that
+// depend on its type when planning: ephemeral buckets keep their data in memory, so
+// they have no storage backend, their own eviction policies and can't wait for writes
+// to be persisted, while the magma storage backend needs a larger memory quota.
+// Settings only known after apply are left out.
+func customizeDiffBucketType(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	config := d.GetRawConfig()
+	if config.IsNull() || !config.IsKnown() {
+		return nil
+	}
+	bucketType := bucketTypeCouchbase
+	if value := config.GetAttr("bucket_type"); !value.IsKnown() {
+		return nil
+	} else if !value.IsNull() {
+		bucketType = value.AsString()
+	}
+
+	var errs *multierror.Error
+	if policy := configString(config.GetAttr("eviction_policy")); policy != "" && !Has(bucketEvictionPolicies[bucketType], policy) {
+		errs = multierror.Append(errs, fmt.Errorf(BucketEvictionPolicyNotSupported, bucketType, strings.Join(bucketEvictionPolicies[bucketType], ", "), policy))
+	}
+	backend := configString(config.GetAttr("storage_backend"))
+	if backend != "" && bucketType == bucketTypeEphemeral {
+		errs = multierror.Append(errs, fmt.Errorf(BucketStorageBackendNotSupported))
+	}
+	if level := configString(config.GetAttr("durability_level")); level != "" && bucketType == bucketTypeEphemeral && !Has(ephemeralBucketDurabilityLevels, level) {
+		errs = multierror.Append(errs, fmt.Errorf(BucketDurabilityLevelNotSupported, level))
+	}
+	if memoryQuota := configInt(config.GetAttr("memory_quota")); backend == bucketStorageMagma && memoryQuota > 0 && memoryQuota < minMagmaMemoryQuota {
+		errs = multierror.Append(errs, fmt.Errorf(BucketInvalidMagmaMemoryQuota, minMagmaMemoryQuota, memoryQuota))
+	}
+	return errs.ErrorOrNil()
+}
+
+// vpcBucketFixedSettings are the settings of a bucket that can be updated in place in a
+// hosted cluster but not in a vpc cluster, as the v2 endpoint updating a bucket only
+// takes its memory quota.
+var vpcBucketFixedSettings = []string{"replicas", "durability_level", "max_ttl", "flush_enabled"}
+
+// customizeDiffBucketUpdate is responsible for rejecting changes to the settings of a
+// bucket in a vpc cluster that the v2 endpoints can't update when planning, rather than
+// sending settings Capella may ignore. The cluster is only looked up when one of them
+// changes.
+func customizeDiffBucketUpdate(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" {
+		return nil
+	}
+	var changed []string
+	for _, key := range vpcBucketFixedSettings {
+		if d.HasChange(key) {
+			changed = append(changed, key)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	kind, diags := getClusterKind(ctx, meta.(capellaClient), d.Get("cluster_id").(string))
+	if diags.HasError() {
+		return fmt.Errorf("%s: %s", diags[0].Summary, diags[0].Detail)
+	}
+	if kind != vpcClusterKind {
+		return nil
+	}
+	var errs *multierror.Error
+	for _, key := range changed {
+		errs = multierror.Append(errs, fmt.Errorf(BucketVpcSettingNotUpdatable, key))
+	}
+	return errs.ErrorOrNil()
+}
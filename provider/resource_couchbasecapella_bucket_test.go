@@ -11,13 +11,17 @@ package provider
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	couchbasecapella "github.com/couchbasecloud/couchbase-capella-api-go-client"
+	"github.com/couchbasecloud/terraform-provider-couchbasecapella/provider/internal/capellamock"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
@@ -25,7 +29,7 @@ import (
 // successfully
 func TestAccCouchbaseCapellaBucket_withSequentialNumberResolution(t *testing.T) {
 	var (
-		bucket couchbasecapella.CouchbaseBucketSpec
+		bucket bucketSpec
 	)
 
 	testClusterId := os.Getenv("CBC_CLUSTER_ID")
@@ -66,7 +70,7 @@ func TestAccCouchbaseCapellaBucket_withSequentialNumberResolution(t *testing.T)
 // successfully
 func TestAccCouchbaseCapellaBucket_withLastWriteWinsResolution(t *testing.T) {
 	var (
-		bucket couchbasecapella.CouchbaseBucketSpec
+		bucket bucketSpec
 	)
 
 	testClusterId := os.Getenv("CBC_CLUSTER_ID")
@@ -103,28 +107,608 @@ func TestAccCouchbaseCapellaBucket_withLastWriteWinsResolution(t *testing.T) {
 	})
 }
 
-// Test to see if bucket has been destroyed after Terraform Destory has been executed
-func testAccCheckCouchbaseCapellaBucketDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*couchbasecapella.APIClient)
-	auth := context.WithValue(
-		context.Background(),
-		couchbasecapella.ContextAPIKeys,
-		map[string]couchbasecapella.APIKey{
-			"accessKey": {
-				Key: os.Getenv("CBC_ACCESS_KEY"),
+// Test to see if the bucket CRUD functions make the expected calls to the Capella API
+func TestBucketCRUD(t *testing.T) {
+	raw := func(clusterId string) map[string]interface{} {
+		return map[string]interface{}{
+			"cluster_id":          clusterId,
+			"name":                "bucket",
+			"memory_quota":        128,
+			"conflict_resolution": "seqno",
+		}
+	}
+	updated := func(clusterId string) map[string]interface{} {
+		raw := raw(clusterId)
+		raw["memory_quota"] = 256
+		return raw
+	}
+	withSettings := func(clusterId string) map[string]interface{} {
+		raw := raw(clusterId)
+		raw["replicas"] = 2
+		raw["durability_level"] = "majority"
+		raw["eviction_policy"] = "fullEviction"
+		raw["max_ttl"] = 3600
+		raw["storage_backend"] = "magma"
+		raw["memory_quota"] = 1024
+		raw["flush_enabled"] = true
+		return raw
+	}
+	addCluster := func(f *fakeCapellaClient) {
+		f.vpcClusters["vpc-cluster-1"] = vpcCluster{Cluster: couchbasecapella.Cluster{Id: "vpc-cluster-1", Status: couchbasecapella.CLUSTERSTATUS_READY}}
+	}
+	addBucket := func(f *fakeCapellaClient) string {
+		addCluster(f)
+		f.buckets["vpc-cluster-1"] = []bucketSpec{{Id: "bucket", Name: "bucket", MemoryQuota: 128}}
+		return "bucket"
+	}
+	addHostedCluster := func(f *fakeCapellaClient) {
+		f.hostedClusters["hosted-cluster-1"] = hostedCluster{V3Cluster: couchbasecapella.V3Cluster{Id: "hosted-cluster-1"}}
+	}
+	addHostedBucket := func(f *fakeCapellaClient) string {
+		addHostedCluster(f)
+		f.buckets["hosted-cluster-1"] = []bucketSpec{{Id: "id-bucket", Name: "bucket", MemoryQuota: 128}}
+		return "bucket"
+	}
+
+	runCRUDTestCases(t, resourceCouchbaseCapellaBucket(), []crudTestCase{
+		{
+			name:  "create",
+			raw:   raw("vpc-cluster-1"),
+			crud:  resourceCouchbaseCapellaBucketCreate,
+			setup: func(f *fakeCapellaClient) string { addCluster(f); return "" },
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if buckets := f.buckets["vpc-cluster-1"]; d.Id() != "bucket" || len(buckets) != 1 || buckets[0].ConflictResolution != "seqno" {
+					t.Fatalf("expected the bucket to be created, got %v", buckets)
+				}
 			},
-			"secretKey": {
-				Key: os.Getenv("CBC_SECRET_KEY"),
+		},
+		{
+			name:  "create with settings",
+			raw:   withSettings("vpc-cluster-1"),
+			crud:  resourceCouchbaseCapellaBucketCreate,
+			setup: func(f *fakeCapellaClient) string { addCluster(f); return "" },
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				want := bucketSpec{
+					Id:                 "bucket",
+					Name:               "bucket",
+					MemoryQuota:        1024,
+					Replicas:           2,
+					ConflictResolution: "seqno",
+					DurabilityLevel:    "majority",
+					EvictionPolicy:     "fullEviction",
+					MaxTTL:             3600,
+					StorageBackend:     "magma",
+					BucketType:         "couchbase",
+					FlushEnabled:       true,
+				}
+				if buckets := f.buckets["vpc-cluster-1"]; len(buckets) != 1 || buckets[0] != want {
+					t.Fatalf("expected the bucket to be created with its settings, got %+v", buckets)
+				}
 			},
 		},
-	)
+		{
+			name:  "create in a hosted cluster",
+			raw:   raw("hosted-cluster-1"),
+			crud:  resourceCouchbaseCapellaBucketCreate,
+			setup: func(f *fakeCapellaClient) string { addHostedCluster(f); return "" },
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if buckets := f.buckets["hosted-cluster-1"]; d.Id() != "bucket" || len(buckets) != 1 || buckets[0].MemoryQuota != 128 {
+					t.Fatalf("expected the bucket to be created through the v3 endpoints, got %v", buckets)
+				}
+				if f.called("CreateBucket") {
+					t.Fatalf("expected the v2 endpoints not to be called for a hosted cluster")
+				}
+			},
+		},
+		{
+			name:    "create in a missing cluster",
+			raw:     raw("vpc-cluster-1"),
+			crud:    resourceCouchbaseCapellaBucketCreate,
+			wantErr: ClusterProblemAccessing + ": " + string(ErrNotFound),
+		},
+		{
+			name: "create when the cluster can't be read",
+			raw:  raw("vpc-cluster-1"),
+			crud: resourceCouchbaseCapellaBucketCreate,
+			setup: func(f *fakeCapellaClient) string {
+				f.errs["GetVpcCluster"] = http.StatusForbidden
+				addCluster(f)
+				return ""
+			},
+			wantErr: ClusterProblemAccessing + ": " + string(ErrForbidden),
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if f.called("GetHostedCluster") || f.called("CreateHostedBucket") {
+					t.Fatalf("expected a vpc cluster that can't be read not to be taken for a hosted cluster, got the calls %v", f.calls)
+				}
+			},
+		},
+		{
+			name:  "read",
+			raw:   raw("vpc-cluster-1"),
+			crud:  resourceCouchbaseCapellaBucketRead,
+			setup: addBucket,
+		},
+		{
+			name:  "read in a hosted cluster",
+			raw:   raw("hosted-cluster-1"),
+			crud:  resourceCouchbaseCapellaBucketRead,
+			setup: addHostedBucket,
+		},
+		{
+			name: "read fails",
+			raw:  raw("vpc-cluster-1"),
+			crud: resourceCouchbaseCapellaBucketRead,
+			setup: func(f *fakeCapellaClient) string {
+				f.errs["ListBuckets"] = http.StatusForbidden
+				return addBucket(f)
+			},
+			wantErr: "Read Bucket: " + string(ErrForbidden),
+		},
+		{
+			name:  "update",
+			raw:   updated("vpc-cluster-1"),
+			crud:  resourceCouchbaseCapellaBucketUpdate,
+			setup: addBucket,
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if buckets := f.buckets["vpc-cluster-1"]; len(buckets) != 1 || buckets[0].MemoryQuota != 256 || !f.called("UpdateBucket") {
+					t.Fatalf("expected the memory quota of the bucket to be updated, got %v", buckets)
+				}
+				if memoryQuota := d.Get("memory_quota").(int); memoryQuota != 256 {
+					t.Fatalf("expected the new memory quota to be read, got %d", memoryQuota)
+				}
+			},
+		},
+		{
+			name: "update settings in a hosted cluster",
+			raw: func() map[string]interface{} {
+				raw := raw("hosted-cluster-1")
+				raw["replicas"] = 3
+				raw["durability_level"] = "persistToMajority"
+				raw["max_ttl"] = 60
+				raw["flush_enabled"] = true
+				return raw
+			}(),
+			crud:  resourceCouchbaseCapellaBucketUpdate,
+			setup: addHostedBucket,
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				bucket := f.buckets["hosted-cluster-1"][0]
+				if bucket.Replicas != 3 || bucket.DurabilityLevel != "persistToMajority" || bucket.MaxTTL != 60 || !bucket.FlushEnabled {
+					t.Fatalf("expected the settings of the bucket to be updated, got %+v", bucket)
+				}
+				if replicas := d.Get("replicas").(int); replicas != 3 {
+					t.Fatalf("expected the new replicas to be read, got %d", replicas)
+				}
+			},
+		},
+		{
+			name:  "update in a hosted cluster",
+			raw:   updated("hosted-cluster-1"),
+			crud:  resourceCouchbaseCapellaBucketUpdate,
+			setup: addHostedBucket,
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if buckets := f.buckets["hosted-cluster-1"]; len(buckets) != 1 || buckets[0].MemoryQuota != 256 || !f.called("UpdateHostedBucket") {
+					t.Fatalf("expected the memory quota of the bucket to be updated through the v3 endpoints, got %v", buckets)
+				}
+			},
+		},
+		{
+			name: "update fails",
+			raw:  updated("vpc-cluster-1"),
+			crud: resourceCouchbaseCapellaBucketUpdate,
+			setup: func(f *fakeCapellaClient) string {
+				f.errs["UpdateBucket"] = http.StatusForbidden
+				return addBucket(f)
+			},
+			wantErr: "Update Bucket: " + string(ErrForbidden),
+		},
+		{
+			name:    "update deleted elsewhere",
+			raw:     updated("vpc-cluster-1"),
+			crud:    resourceCouchbaseCapellaBucketUpdate,
+			setup:   func(f *fakeCapellaClient) string { addCluster(f); return "bucket" },
+			wantErr: "Failed to update",
+		},
+		{
+			name:  "delete",
+			raw:   raw("vpc-cluster-1"),
+			crud:  resourceCouchbaseCapellaBucketDelete,
+			setup: addBucket,
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if buckets := f.buckets["vpc-cluster-1"]; len(buckets) != 0 {
+					t.Fatalf("expected the bucket to be deleted, got %v", buckets)
+				}
+			},
+		},
+		{
+			name:  "delete in a hosted cluster",
+			raw:   raw("hosted-cluster-1"),
+			crud:  resourceCouchbaseCapellaBucketDelete,
+			setup: addHostedBucket,
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if buckets := f.buckets["hosted-cluster-1"]; len(buckets) != 0 {
+					t.Fatalf("expected the bucket to be deleted, got %v", buckets)
+				}
+			},
+		},
+	})
+}
+
+// Test to see if the settings of a bucket are updated in place or replace the bucket
+func TestBucketDiff(t *testing.T) {
+	raw := func(change func(raw map[string]interface{})) map[string]interface{} {
+		raw := map[string]interface{}{
+			"cluster_id":          "hosted-cluster-1",
+			"name":                "bucket",
+			"memory_quota":        128,
+			"conflict_resolution": "seqno",
+		}
+		if change != nil {
+			change(raw)
+		}
+		return raw
+	}
+
+	testCases := []struct {
+		name        string
+		new         map[string]interface{}
+		wantReplace bool
+	}{
+		{name: "memory quota", new: raw(func(raw map[string]interface{}) { raw["memory_quota"] = 256 })},
+		{name: "replicas", new: raw(func(raw map[string]interface{}) { raw["replicas"] = 2 })},
+		{name: "durability level", new: raw(func(raw map[string]interface{}) { raw["durability_level"] = "majority" })},
+		{name: "max ttl", new: raw(func(raw map[string]interface{}) { raw["max_ttl"] = 60 })},
+		{name: "flush", new: raw(func(raw map[string]interface{}) { raw["flush_enabled"] = true })},
+		{name: "eviction policy", new: raw(func(raw map[string]interface{}) { raw["eviction_policy"] = "fullEviction" }), wantReplace: true},
+		{name: "storage backend", new: raw(func(raw map[string]interface{}) { raw["storage_backend"] = "couchstore" }), wantReplace: true},
+		{name: "bucket type", new: raw(func(raw map[string]interface{}) { raw["bucket_type"] = "ephemeral" }), wantReplace: true},
+		{name: "conflict resolution", new: raw(func(raw map[string]interface{}) { raw["conflict_resolution"] = "lww" }), wantReplace: true},
+	}
+
+	r := resourceCouchbaseCapellaBucket()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newFakeCapellaClient()
+			client.hostedClusters["hosted-cluster-1"] = hostedCluster{V3Cluster: couchbasecapella.V3Cluster{Id: "hosted-cluster-1"}}
+			_, diff, err := testResourceDiff(t, r, "bucket", raw(nil), tc.new, client)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if diff == nil || len(diff.Attributes) == 0 {
+				t.Fatal("expected the bucket to change")
+			}
+			if diff.RequiresNew() != tc.wantReplace {
+				t.Fatalf("expected replacement to be %t, got %t", tc.wantReplace, diff.RequiresNew())
+			}
+		})
+	}
+}
+
+// Test to see if changes to the settings of a bucket in a vpc cluster that the v2 endpoints
+// can't update are rejected when planning
+func TestBucketDiff_vpcCluster(t *testing.T) {
+	old := map[string]interface{}{
+		"cluster_id":          "vpc-cluster-1",
+		"name":                "bucket",
+		"memory_quota":        128,
+		"conflict_resolution": "seqno",
+	}
+
+	testCases := []struct {
+		name     string
+		key      string
+		value    interface{}
+		setup    func(f *fakeCapellaClient)
+		wantErrs []string
+	}{
+		{name: "memory quota", key: "memory_quota", value: 256},
+		{name: "replicas", key: "replicas", value: 2, wantErrs: []string{fmt.Sprintf(BucketVpcSettingNotUpdatable, "replicas")}},
+		{name: "durability level", key: "durability_level", value: "majority", wantErrs: []string{fmt.Sprintf(BucketVpcSettingNotUpdatable, "durability_level")}},
+		{name: "max ttl", key: "max_ttl", value: 60, wantErrs: []string{fmt.Sprintf(BucketVpcSettingNotUpdatable, "max_ttl")}},
+		{name: "flush", key: "flush_enabled", value: true, wantErrs: []string{fmt.Sprintf(BucketVpcSettingNotUpdatable, "flush_enabled")}},
+		{
+			name:     "cluster can't be read",
+			key:      "replicas",
+			value:    2,
+			setup:    func(f *fakeCapellaClient) { f.errs["GetVpcCluster"] = http.StatusForbidden },
+			wantErrs: []string{ClusterProblemAccessing},
+		},
+	}
+
+	r := resourceCouchbaseCapellaBucket()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newFakeCapellaClient()
+			client.vpcClusters["vpc-cluster-1"] = vpcCluster{Cluster: couchbasecapella.Cluster{Id: "vpc-cluster-1", Status: couchbasecapella.CLUSTERSTATUS_READY}}
+			if tc.setup != nil {
+				tc.setup(client)
+			}
+			updated := make(map[string]interface{}, len(old)+1)
+			for key, value := range old {
+				updated[key] = value
+			}
+			updated[tc.key] = tc.value
+
+			_, _, err := testResourceDiff(t, r, "bucket", old, updated, client)
+			if len(tc.wantErrs) == 0 {
+				if err != nil {
+					t.Fatalf("err: %s", err)
+				}
+				return
+			}
+			for _, want := range tc.wantErrs {
+				if err == nil || !strings.Contains(err.Error(), want) {
+					t.Fatalf("expected the error %q, got %v", want, err)
+				}
+			}
+		})
+	}
+}
+
+// Test to see if the settings of a bucket that depend on its type are validated when planning
+func TestBucketDiff_bucketType(t *testing.T) {
+	raw := func(settings map[string]interface{}) map[string]interface{} {
+		raw := map[string]interface{}{
+			"cluster_id":          "vpc-cluster-1",
+			"name":                "bucket",
+			"memory_quota":        128,
+			"conflict_resolution": "seqno",
+		}
+		for key, value := range settings {
+			raw[key] = value
+		}
+		return raw
+	}
+
+	testCases := []struct {
+		name    string
+		raw     map[string]interface{}
+		wantErr string
+	}{
+		{name: "couchbase defaults", raw: raw(nil)},
+		{name: "ephemeral defaults", raw: raw(map[string]interface{}{"bucket_type": "ephemeral"})},
+		{name: "couchbase full eviction", raw: raw(map[string]interface{}{"eviction_policy": "fullEviction"})},
+		{name: "couchbase no eviction", raw: raw(map[string]interface{}{"eviction_policy": "noEviction"}), wantErr: "couchbase buckets support the eviction policies"},
+		{name: "ephemeral nru eviction", raw: raw(map[string]interface{}{"bucket_type": "ephemeral", "eviction_policy": "nruEviction"})},
+		{name: "ephemeral value only eviction", raw: raw(map[string]interface{}{"bucket_type": "ephemeral", "eviction_policy": "valueOnly"}), wantErr: "ephemeral buckets support the eviction policies"},
+		{name: "ephemeral storage backend", raw: raw(map[string]interface{}{"bucket_type": "ephemeral", "storage_backend": "couchstore"}), wantErr: BucketStorageBackendNotSupported},
+		{name: "ephemeral majority durability", raw: raw(map[string]interface{}{"bucket_type": "ephemeral", "durability_level": "majority"})},
+		{name: "ephemeral persisted durability", raw: raw(map[string]interface{}{"bucket_type": "ephemeral", "durability_level": "persistToMajority"}), wantErr: "ephemeral buckets support the durability levels"},
+		{name: "magma", raw: raw(map[string]interface{}{"storage_backend": "magma", "memory_quota": 1024})},
+		{name: "magma small memory quota", raw: raw(map[string]interface{}{"storage_backend": "magma"}), wantErr: "the magma storage backend needs a memory quota of at least 1024 MiB"},
+	}
+
+	r := resourceCouchbaseCapellaBucket()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := testResourceDiff(t, r, "", nil, tc.raw, newFakeCapellaClient())
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("err: %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected the error %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+// Test to see if a new bucket is read once it shows up in the list of buckets of the cluster,
+// even when listing the buckets fails at first
+func TestBucketCreate_listDelay(t *testing.T) {
+	server, client := newTestMockClient(t)
+	server.ListDelay = 200 * time.Millisecond
+	projectId := server.AddProject("project")
+	clusterId := server.AddVpcCluster(projectId, server.AddCloud("aws", "us-east-1", "10.0.0.0/16"), "cluster")
+	server.Inject(capellamock.Fault{Method: http.MethodGet, Path: "v2/clusters/*/buckets", Count: 1, Status: http.StatusBadGateway})
+
+	d := schema.TestResourceDataRaw(t, resourceCouchbaseCapellaBucket().Schema, map[string]interface{}{
+		"cluster_id":          clusterId,
+		"name":                "bucket",
+		"memory_quota":        128,
+		"conflict_resolution": "seqno",
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if diags := resourceCouchbaseCapellaBucketCreate(ctx, d, client); diags.HasError() {
+		t.Fatalf("err: %v", diags)
+	}
+	if d.Id() != "bucket" {
+		t.Fatalf("expected the bucket to be read, got %q", d.Id())
+	}
+}
+
+// Test to see if an update of the memory quota of a bucket waits for the new memory quota
+// to show in the list of buckets of the cluster before reading the bucket
+func TestBucketUpdate_listDelay(t *testing.T) {
+	server, client := newTestMockClient(t)
+	server.ListDelay = 200 * time.Millisecond
+	clusterId := server.AddVpcCluster(server.AddProject("project"), server.AddCloud("aws", "us-east-1", "10.0.0.0/16"), "cluster")
+	server.AddBucket(clusterId, "bucket", 128)
+
+	r := resourceCouchbaseCapellaBucket()
+	raw := map[string]interface{}{
+		"cluster_id":          clusterId,
+		"name":                "bucket",
+		"memory_quota":        128,
+		"conflict_resolution": "seqno",
+	}
+	updated := map[string]interface{}{
+		"cluster_id":          clusterId,
+		"name":                "bucket",
+		"memory_quota":        256,
+		"conflict_resolution": "seqno",
+	}
+	d := testResourceDataUpdate(t, r, "bucket", raw, updated, client)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if diags := resourceCouchbaseCapellaBucketUpdate(ctx, d, client); diags.HasError() {
+		t.Fatalf("err: %v", diags)
+	}
+	if memoryQuota := d.Get("memory_quota").(int); memoryQuota != 256 {
+		t.Fatalf("expected the new memory quota to be read, got %d", memoryQuota)
+	}
+	buckets, _, err := client.ListBuckets(ctx, clusterId)
+	if err != nil || len(buckets) != 1 || buckets[0].MemoryQuota != 256 {
+		t.Fatalf("expected the memory quota of the bucket to be updated, got %+v: %v", buckets, err)
+	}
+}
+
+// Test to see if a bucket in a hosted cluster is created, read, updated and deleted through the v3 bucket endpoints
+func TestBucketCRUD_hostedCluster(t *testing.T) {
+	server, client := newTestMockClient(t)
+	clusterId := server.AddHostedCluster(server.AddProject("project"), "cluster", "aws", "us-west-2", "10.0.16.0/20")
+
+	r := resourceCouchbaseCapellaBucket()
+	raw := map[string]interface{}{
+		"cluster_id":          clusterId,
+		"name":                "bucket",
+		"memory_quota":        128,
+		"conflict_resolution": "lww",
+	}
+	d := schema.TestResourceDataRaw(t, r.Schema, raw)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if diags := resourceCouchbaseCapellaBucketCreate(ctx, d, client); diags.HasError() {
+		t.Fatalf("err: %v", diags)
+	}
+	buckets, _, err := client.ListHostedBuckets(ctx, clusterId)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(buckets) != 1 || buckets[0].Name != "bucket" || buckets[0].MemoryQuota != 128 || buckets[0].ConflictResolution != "lww" {
+		t.Fatalf("expected the bucket to be created, got %+v", buckets)
+	}
+
+	updated := map[string]interface{}{
+		"cluster_id":          clusterId,
+		"name":                "bucket",
+		"memory_quota":        256,
+		"conflict_resolution": "lww",
+	}
+	d = testResourceDataUpdate(t, r, "bucket", raw, updated, client)
+	if diags := resourceCouchbaseCapellaBucketUpdate(ctx, d, client); diags.HasError() {
+		t.Fatalf("err: %v", diags)
+	}
+	if buckets, _, err := client.ListHostedBuckets(ctx, clusterId); err != nil || len(buckets) != 1 || buckets[0].MemoryQuota != 256 {
+		t.Fatalf("expected the memory quota of the bucket to be updated, got %+v: %v", buckets, err)
+	}
+
+	if diags := resourceCouchbaseCapellaBucketDelete(ctx, d, client); diags.HasError() {
+		t.Fatalf("err: %v", diags)
+	}
+	if buckets, _, err := client.ListHostedBuckets(ctx, clusterId); err != nil || len(buckets) != 0 {
+		t.Fatalf("expected the bucket to be deleted, got %+v: %v", buckets, err)
+	}
+}
+
+// Test to see if a bucket of a hosted cluster is read when it is past the first page of the list of buckets
+func TestBucketRead_hostedClusterPages(t *testing.T) {
+	server, client := newTestMockClient(t)
+	clusterId := server.AddHostedCluster(server.AddProject("project"), "cluster", "aws", "us-west-2", "10.0.16.0/20")
+	for i := 0; i < 25; i++ {
+		server.AddBucket(clusterId, fmt.Sprintf("bucket-%02d", i), 128)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	buckets, _, err := client.ListHostedBuckets(ctx, clusterId)
+	if err != nil || len(buckets) != 25 {
+		t.Fatalf("expected every page of buckets to be listed, got %d buckets: %v", len(buckets), err)
+	}
+
+	d := schema.TestResourceDataRaw(t, resourceCouchbaseCapellaBucket().Schema, map[string]interface{}{
+		"cluster_id":          clusterId,
+		"name":                "bucket-24",
+		"memory_quota":        128,
+		"conflict_resolution": "seqno",
+	})
+	d.SetId("bucket-24")
+	if diags := resourceCouchbaseCapellaBucketRead(ctx, d, client); diags.HasError() {
+		t.Fatalf("err: %v", diags)
+	}
+	if d.Id() != "bucket-24" {
+		t.Fatalf("expected the bucket on the last page to be read, got %q", d.Id())
+	}
+}
+
+// Test to see if the settings of a bucket are created, read and updated through the v2 and v3 bucket endpoints
+func TestBucketCRUD_settings(t *testing.T) {
+	server, client := newTestMockClient(t)
+	projectId := server.AddProject("project")
+	clusterIds := map[string]string{
+		"vpc":    server.AddVpcCluster(projectId, server.AddCloud("aws", "us-east-1", "10.0.0.0/16"), "vpc"),
+		"hosted": server.AddHostedCluster(projectId, "hosted", "aws", "us-west-2", "10.0.16.0/20"),
+	}
+
+	r := resourceCouchbaseCapellaBucket()
+	for kind, clusterId := range clusterIds {
+		t.Run(kind, func(t *testing.T) {
+			raw := map[string]interface{}{
+				"cluster_id":          clusterId,
+				"name":                "bucket",
+				"memory_quota":        128,
+				"bucket_type":         "ephemeral",
+				"durability_level":    "majority",
+				"max_ttl":             3600,
+				"replicas":            0,
+				"conflict_resolution": "lww",
+			}
+			d := schema.TestResourceDataRaw(t, r.Schema, raw)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if diags := resourceCouchbaseCapellaBucketCreate(ctx, d, client); diags.HasError() {
+				t.Fatalf("err: %v", diags)
+			}
+			if policy, backend := d.Get("eviction_policy").(string), d.Get("storage_backend").(string); policy != "noEviction" || backend != "" {
+				t.Fatalf("expected the defaults of an ephemeral bucket to be read, got %q and %q", policy, backend)
+			}
+			if ttl, replicas := d.Get("max_ttl").(int), d.Get("replicas").(int); ttl != 3600 || replicas != 0 {
+				t.Fatalf("expected the max TTL and replicas to be read, got %d and %d", ttl, replicas)
+			}
+
+			// Only the memory quota of a bucket in a vpc cluster can be updated in place.
+			updated := map[string]interface{}{}
+			for key, value := range raw {
+				updated[key] = value
+			}
+			updated["memory_quota"] = 256
+			if kind == "hosted" {
+				updated["replicas"] = 2
+				updated["max_ttl"] = 0
+				updated["flush_enabled"] = true
+			}
+			d = testResourceDataUpdate(t, r, "bucket", raw, updated, client)
+			if diags := resourceCouchbaseCapellaBucketUpdate(ctx, d, client); diags.HasError() {
+				t.Fatalf("err: %v", diags)
+			}
+			if memoryQuota := d.Get("memory_quota").(int); memoryQuota != 256 {
+				t.Fatalf("expected the new memory quota to be read, got %d", memoryQuota)
+			}
+			if replicas, ttl, flush := d.Get("replicas").(int), d.Get("max_ttl").(int), d.Get("flush_enabled").(bool); kind == "hosted" && (replicas != 2 || ttl != 0 || !flush) {
+				t.Fatalf("expected the new settings to be read, got %d, %d and %t", replicas, ttl, flush)
+			}
+		})
+	}
+}
+
+// Test to see if bucket has been destroyed after Terraform Destory has been executed
+func testAccCheckCouchbaseCapellaBucketDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*Client)
+	ctx := context.Background()
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "couchbasecapella_bucket" {
 			continue
 		}
 
-		buckets, _, err := client.ClustersApi.ClustersListBuckets(auth, rs.Primary.Attributes["cluster_id"]).Execute()
+		clusterId := rs.Primary.Attributes["cluster_id"]
+		kind, diags := getClusterKind(ctx, client, clusterId)
+		if diags.HasError() {
+			return fmt.Errorf("%v", diags)
+		}
+		buckets, _, err := listBuckets(ctx, client, clusterId, kind)
 		if err != nil {
 			return fmt.Errorf("%s", err)
 		}
@@ -139,21 +723,10 @@ func testAccCheckCouchbaseCapellaBucketDestroy(s *terraform.State) error {
 }
 
 // Test to see if bucket exists after Terraform Apply has been executed
-func testAccCheckCouchbaseCapellaBucketExists(resourceName string, bucket *couchbasecapella.CouchbaseBucketSpec) resource.TestCheckFunc {
+func testAccCheckCouchbaseCapellaBucketExists(resourceName string, bucket *bucketSpec) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
-		client := testAccProvider.Meta().(*couchbasecapella.APIClient)
-		auth := context.WithValue(
-			context.Background(),
-			couchbasecapella.ContextAPIKeys,
-			map[string]couchbasecapella.APIKey{
-				"accessKey": {
-					Key: os.Getenv("CBC_ACCESS_KEY"),
-				},
-				"secretKey": {
-					Key: os.Getenv("CBC_SECRET_KEY"),
-				},
-			},
-		)
+		client := testAccProvider.Meta().(*Client)
+		ctx := context.Background()
 
 		rs, ok := s.RootModule().Resources[resourceName]
 		if !ok {
@@ -163,18 +736,23 @@ func testAccCheckCouchbaseCapellaBucketExists(resourceName string, bucket *couch
 		if rs.Primary.ID == "" {
 			return fmt.Errorf("no bucket id is set")
 		}
+		clusterId := rs.Primary.Attributes["cluster_id"]
+		kind, diags := getClusterKind(ctx, client, clusterId)
+		if diags.HasError() {
+			return fmt.Errorf("%v", diags)
+		}
 
 		// NOTE: There is a delay for retrieving a newly created bucket from Capella's list of buckets.
 		// This poll will check at regular intervals if the newly created bucket is in the list of buckets
 		// until the timeout period expires. If the timeout is reached, then the newly created bucket is not in the list of buckets.
 		timeout := time.NewTimer(time.Second * 60)
-		ticker := time.NewTicker(time.Second * 3)
+		ticker := time.NewTicker(listPollInterval)
 		for {
 			select {
 			case <-timeout.C:
 				return fmt.Errorf("bucket does not exist")
 			case <-ticker.C:
-				buckets, _, err := client.ClustersApi.ClustersListBuckets(auth, rs.Primary.Attributes["cluster_id"]).Execute()
+				buckets, _, err := listBuckets(ctx, client, clusterId, kind)
 				if err != nil {
 					return fmt.Errorf("%s", err)
 				}
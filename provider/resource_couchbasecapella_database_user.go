@@ -10,7 +10,7 @@ package provider
 
 import (
 	"context"
-	"fmt"
+	"net/http"
 	"time"
 	"unicode"
 
@@ -89,26 +89,14 @@ func resourceCouchbaseCapellaDatabaseUser() *schema.Resource {
 }
 
 // resourceCouchbaseCapellaDatabaseUserCreate is responsible for creating a
-// database user in a Couchbase Capella VPC Cluster using the Terraform resource data.
-// WARNING: Creating database users is only supported for VPC Clusters in this current
-// release.
+// database user in a Couchbase Capella Cluster using the Terraform resource data.
 func resourceCouchbaseCapellaDatabaseUserCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*couchbasecapella.APIClient)
-	auth := getAuth(ctx)
+	client := meta.(capellaClient)
 
 	clusterId := d.Get("cluster_id").(string)
-
-	// Check if the Cluster is inVPC to create the users
-	// Managing buckets is not available for hosted clusters
-	_, _, err := client.ClustersApi.ClustersShow(auth, clusterId).Execute()
-
-	if err != nil {
-		// Check V3Cluster :: Need to be fixed in next versions
-		_, _, err3 := client.ClustersV3Api.ClustersV3show(auth, clusterId).Execute()
-		if err3 != nil {
-			return diag.FromErr(fmt.Errorf(ClusterProblemAccessing))
-		}
-		return diag.FromErr(fmt.Errorf(DatabaseUserHostedNotSupported))
+	kind, diags := getClusterKind(ctx, client, clusterId)
+	if diags.HasError() {
+		return diags
 	}
 
 	username := d.Get("username").(string)
@@ -116,9 +104,9 @@ func resourceCouchbaseCapellaDatabaseUserCreate(ctx context.Context, d *schema.R
 
 	// Check to see if a user with the same name already exists in the cluster. If a user
 	// already has the name, an error is thrown. If not, then proceeds with creation.
-	users, _, err := client.ClustersApi.ClustersListUsers(auth, clusterId).Execute()
+	users, r, err := listDatabaseUsers(ctx, client, clusterId, kind)
 	if err != nil {
-		return diag.FromErr(err)
+		return manageErrors(err, r, "Create Database User")
 	}
 	for _, user := range users {
 		if user.Username == username {
@@ -151,12 +139,13 @@ func resourceCouchbaseCapellaDatabaseUserCreate(ctx context.Context, d *schema.R
 		return diag.Errorf("Please specify only access for specific buckets or access for all buckets")
 	}
 
-	r, err := client.ClustersApi.ClustersCreateUser(auth, clusterId).CreateDatabaseUserRequest(createDatabaseUserRequest).Execute()
-	if r == nil {
-		return diag.Errorf("Pointer to database user create http.Response is nil")
+	if kind == hostedClusterKind {
+		r, err = client.CreateHostedDatabaseUser(ctx, clusterId, createDatabaseUserRequest)
+	} else {
+		r, err = client.CreateDatabaseUser(ctx, clusterId, createDatabaseUserRequest)
 	}
 	if err != nil {
-		return manageErrors(err, *r, "Create Database User")
+		return manageErrors(err, r, "Create Database User")
 	}
 
 	d.SetId(username)
@@ -167,21 +156,11 @@ func resourceCouchbaseCapellaDatabaseUserCreate(ctx context.Context, d *schema.R
 // resourceCouchbaseCapellaDatabaseUserRead is responsible for reading a Couchbase
 // Capella database user using the Terraform resource data.
 func resourceCouchbaseCapellaDatabaseUserRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*couchbasecapella.APIClient)
-	auth := getAuth(ctx)
+	client := meta.(capellaClient)
 	clusterId := d.Get("cluster_id").(string)
-
-	// Check if the Cluster is inVPC to read the db users
-	// Managing buckets is not available for hosted clusters
-	_, _, err := client.ClustersApi.ClustersShow(auth, clusterId).Execute()
-
-	if err != nil {
-		// Check V3Cluster :: Need to be fixed in next versions
-		_, _, err3 := client.ClustersV3Api.ClustersV3show(auth, clusterId).Execute()
-		if err3 != nil {
-			return diag.FromErr(fmt.Errorf(ClusterProblemAccessing))
-		}
-		return diag.FromErr(fmt.Errorf(DatabaseUserHostedNotSupported))
+	kind, diags := getClusterKind(ctx, client, clusterId)
+	if diags.HasError() {
+		return diags
 	}
 
 	// The current version of the Capella API doesn't support getting a singular
@@ -189,17 +168,21 @@ func resourceCouchbaseCapellaDatabaseUserRead(ctx context.Context, d *schema.Res
 	// the list of all database users and check if it exists. If the user
 	// is not present in the list of users and error is thrown.
 	timeout := time.NewTimer(time.Second * 120)
-	ticker := time.NewTicker(time.Second * 2)
+	ticker := time.NewTicker(listPollInterval)
+	defer timeout.Stop()
+	defer ticker.Stop()
 	for {
 		select {
+		case <-ctx.Done():
+			return diag.FromErr(ctx.Err())
 		case <-timeout.C:
 			username := d.Id()
 			d.SetId("")
 			return diag.Errorf("Error 404: Failed to find the username %s ", username)
 		case <-ticker.C:
-			users, _, err := client.ClustersApi.ClustersListUsers(auth, clusterId).Execute()
+			users, r, err := listDatabaseUsers(ctx, client, clusterId, kind)
 			if err != nil {
-				return diag.FromErr(err)
+				return manageErrors(err, r, "Read Database User")
 			}
 			for _, user := range users {
 				if user.Username == d.Id() {
@@ -211,25 +194,13 @@ func resourceCouchbaseCapellaDatabaseUserRead(ctx context.Context, d *schema.Res
 }
 
 // resourceCouchbaseCapellaDatabaseUserUpdate is responsible for updating a
-// database user in a Couchbase Capella VPC Cluster using the Terraform resource data.
-// WARNING: Updating database users is only supported for VPC Clusters in this current
-// release.
+// database user in a Couchbase Capella Cluster using the Terraform resource data.
 func resourceCouchbaseCapellaDatabaseUserUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*couchbasecapella.APIClient)
-	auth := getAuth(ctx)
+	client := meta.(capellaClient)
 	clusterId := d.Get("cluster_id").(string)
-
-	// Check if the Cluster is inVPC to update the users
-	// Managing buckets is not available for hosted clusters
-	_, _, err := client.ClustersApi.ClustersShow(auth, clusterId).Execute()
-
-	if err != nil {
-		// Check V3Cluster :: Need to be fixed in next versions
-		_, _, err3 := client.ClustersV3Api.ClustersV3show(auth, clusterId).Execute()
-		if err3 != nil {
-			return diag.FromErr(fmt.Errorf(ClusterProblemAccessing))
-		}
-		return diag.FromErr(fmt.Errorf(DatabaseUserHostedNotSupported))
+	kind, diags := getClusterKind(ctx, client, clusterId)
+	if diags.HasError() {
+		return diags
 	}
 
 	username := d.Get("username").(string)
@@ -246,38 +217,38 @@ func resourceCouchbaseCapellaDatabaseUserUpdate(ctx context.Context, d *schema.R
 		updateDatabaseUserRequest.SetBuckets(buckets)
 	}
 
-	r, err := client.ClustersApi.ClustersUpdateUser(auth, clusterId, username).UpdateDatabaseUserRequest(updateDatabaseUserRequest).Execute()
-	if r == nil {
-		return diag.Errorf("Pointer to database user update http.Response is nil")
+	var r *http.Response
+	var err error
+	if kind == hostedClusterKind {
+		// The v3 endpoints update a database user by its id rather than its username.
+		var user *couchbasecapella.ListDatabaseUsersResponseItem
+		user, r, err = findDatabaseUser(ctx, client, clusterId, kind, username)
+		if err != nil {
+			return manageErrors(err, r, "Update Database User")
+		}
+		if user == nil {
+			return diag.Errorf("Failed to update: Database User doesn't exist in list of users")
+		}
+		r, err = client.UpdateHostedDatabaseUser(ctx, clusterId, user.GetUserId(), updateDatabaseUserRequest)
+	} else {
+		r, err = client.UpdateDatabaseUser(ctx, clusterId, username, updateDatabaseUserRequest)
 	}
 	if err != nil {
-		return manageErrors(err, *r, "Update Database User")
+		return manageErrors(err, r, "Update Database User")
 	}
 
 	return resourceCouchbaseCapellaDatabaseUserRead(ctx, d, meta)
 }
 
 // resourceCouchbaseCapellaDatabaseUserDelete is responsible for deleting a
-// database user in a Couchbase Capella VPC Cluster using the Terraform resource data.
-// WARNING: Deleting database users is only supported for VPC Clusters in this current
-// release.
+// database user in a Couchbase Capella Cluster using the Terraform resource data.
 func resourceCouchbaseCapellaDatabaseUserDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*couchbasecapella.APIClient)
-	auth := getAuth(ctx)
+	client := meta.(capellaClient)
 
 	clusterId := d.Get("cluster_id").(string)
-
-	// Check if the Cluster is inVPC to delete the users
-	// Managing buckets is not available for hosted clusters
-	_, _, err := client.ClustersApi.ClustersShow(auth, clusterId).Execute()
-
-	if err != nil {
-		// Check V3Cluster :: Need to be fixed in next versions
-		_, _, err3 := client.ClustersV3Api.ClustersV3show(auth, clusterId).Execute()
-		if err3 != nil {
-			return diag.FromErr(fmt.Errorf(ClusterProblemAccessing))
-		}
-		return diag.FromErr(fmt.Errorf(DatabaseUserHostedNotSupported))
+	kind, diags := getClusterKind(ctx, client, clusterId)
+	if diags.HasError() {
+		return diags
 	}
 
 	username := d.Get("username").(string)
@@ -285,23 +256,46 @@ func resourceCouchbaseCapellaDatabaseUserDelete(ctx context.Context, d *schema.R
 	// Check to see if database user exists in list of database users. If the database user
 	// exists, it will be deleted from the Cluster. If the database user does not appear in the list of users,
 	// likely being deleted elsewhere, an error is thrown.
-	users, _, err := client.ClustersApi.ClustersListUsers(auth, clusterId).Execute()
+	user, r, err := findDatabaseUser(ctx, client, clusterId, kind, username)
 	if err != nil {
-		return diag.FromErr(err)
+		return manageErrors(err, r, "Delete Database User")
 	}
-	for _, user := range users {
-		if user.Username == username {
-			r, err := client.ClustersApi.ClustersDeleteUser(auth, clusterId, username).Execute()
-			if r == nil {
-				return diag.Errorf("Pointer to database user delete http.Response is nil")
-			}
-			if err != nil {
-				return manageErrors(err, *r, "Delete Database User")
-			}
-			return nil
+	if user == nil {
+		return diag.Errorf("Failed to delete: Database User doesn't exist in list of users")
+	}
+	if kind == hostedClusterKind {
+		r, err = client.DeleteHostedDatabaseUser(ctx, clusterId, user.GetUserId())
+	} else {
+		r, err = client.DeleteDatabaseUser(ctx, clusterId, username)
+	}
+	if err != nil {
+		return manageErrors(err, r, "Delete Database User")
+	}
+	return nil
+}
+
+// listDatabaseUsers is responsible for listing the database users of a cluster
+// through the endpoints of its kind.
+func listDatabaseUsers(ctx context.Context, client capellaClient, clusterId string, kind clusterKind) ([]couchbasecapella.ListDatabaseUsersResponseItem, *http.Response, error) {
+	if kind == hostedClusterKind {
+		return client.ListHostedDatabaseUsers(ctx, clusterId)
+	}
+	return client.ListDatabaseUsers(ctx, clusterId)
+}
+
+// findDatabaseUser is responsible for finding a database user of a cluster by its
+// username. It returns nil when the cluster has no such user.
+func findDatabaseUser(ctx context.Context, client capellaClient, clusterId string, kind clusterKind, username string) (*couchbasecapella.ListDatabaseUsersResponseItem, *http.Response, error) {
+	users, r, err := listDatabaseUsers(ctx, client, clusterId, kind)
+	if err != nil {
+		return nil, r, err
+	}
+	for i := range users {
+		if users[i].Username == username {
+			return &users[i], r, nil
 		}
 	}
-	return diag.Errorf("Failed to delete: Database User doesn't exist in list of users")
+	return nil, r, nil
 }
 
 // expandBuckets is responsible for converting the bucket interface into
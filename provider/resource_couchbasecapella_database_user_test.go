@@ -11,12 +11,15 @@ package provider
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"testing"
+	"time"
 
 	couchbasecapella "github.com/couchbasecloud/couchbase-capella-api-go-client"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
@@ -121,28 +124,240 @@ func TestAccCouchbaseCapellaDatabaseUser_specificBucketAccess(t *testing.T) {
 	})
 }
 
-// Test to see if database user has been destroyed after Terraform Destory has been executed
-func testAccCheckCouchbaseCapellaDatabaseUserDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*couchbasecapella.APIClient)
-	auth := context.WithValue(
-		context.Background(),
-		couchbasecapella.ContextAPIKeys,
-		map[string]couchbasecapella.APIKey{
-			"accessKey": {
-				Key: os.Getenv("CBC_ACCESS_KEY"),
+// Test to see if the database user CRUD functions make the expected calls to the Capella API
+func TestDatabaseUserCRUD(t *testing.T) {
+	raw := map[string]interface{}{
+		"cluster_id":        "vpc-cluster-1",
+		"username":          "user",
+		"password":          "Password123!",
+		"all_bucket_access": "data_reader",
+	}
+	withoutAccess := map[string]interface{}{
+		"cluster_id": "vpc-cluster-1",
+		"username":   "user",
+		"password":   "Password123!",
+	}
+	addCluster := func(f *fakeCapellaClient) string {
+		f.vpcClusters["vpc-cluster-1"] = vpcCluster{Cluster: couchbasecapella.Cluster{Id: "vpc-cluster-1", Status: couchbasecapella.CLUSTERSTATUS_READY}}
+		return ""
+	}
+	addUser := func(f *fakeCapellaClient) string {
+		addCluster(f)
+		f.users["vpc-cluster-1"] = []couchbasecapella.ListDatabaseUsersResponseItem{{Username: "user"}}
+		return "user"
+	}
+	addHostedCluster := func(f *fakeCapellaClient) string {
+		f.hostedClusters["vpc-cluster-1"] = hostedCluster{V3Cluster: couchbasecapella.V3Cluster{Id: "vpc-cluster-1"}}
+		return ""
+	}
+	addHostedUser := func(f *fakeCapellaClient) string {
+		addHostedCluster(f)
+		f.users["vpc-cluster-1"] = []couchbasecapella.ListDatabaseUsersResponseItem{{UserId: couchbasecapella.PtrString("id-user"), Username: "user"}}
+		return "user"
+	}
+
+	runCRUDTestCases(t, resourceCouchbaseCapellaDatabaseUser(), []crudTestCase{
+		{
+			name:  "create",
+			raw:   raw,
+			crud:  resourceCouchbaseCapellaDatabaseUserCreate,
+			setup: addCluster,
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if users := f.users["vpc-cluster-1"]; d.Id() != "user" || len(users) != 1 {
+					t.Fatalf("expected the user to be created, got %v", users)
+				}
 			},
-			"secretKey": {
-				Key: os.Getenv("CBC_SECRET_KEY"),
+		},
+		{
+			name:    "create an existing user",
+			raw:     raw,
+			crud:    resourceCouchbaseCapellaDatabaseUserCreate,
+			setup:   addUser,
+			wantErr: "Failed to create: A user already exists with that name",
+		},
+		{
+			name:    "create without access",
+			raw:     withoutAccess,
+			crud:    resourceCouchbaseCapellaDatabaseUserCreate,
+			setup:   addCluster,
+			wantErr: "No bucket access roles specified",
+		},
+		{
+			name: "create fails",
+			raw:  raw,
+			crud: resourceCouchbaseCapellaDatabaseUserCreate,
+			setup: func(f *fakeCapellaClient) string {
+				f.errs["CreateDatabaseUser"] = http.StatusConflict
+				return addCluster(f)
 			},
+			wantErr: "Create Database User: " + string(ErrConflict),
 		},
-	)
+		{
+			name:  "create in a hosted cluster",
+			raw:   raw,
+			crud:  resourceCouchbaseCapellaDatabaseUserCreate,
+			setup: addHostedCluster,
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if users := f.users["vpc-cluster-1"]; d.Id() != "user" || len(users) != 1 || !f.called("CreateHostedDatabaseUser") {
+					t.Fatalf("expected the user to be created through the v3 endpoints, got %v", users)
+				}
+			},
+		},
+		{
+			name:  "update in a hosted cluster",
+			raw:   raw,
+			crud:  resourceCouchbaseCapellaDatabaseUserUpdate,
+			setup: addHostedUser,
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if !f.called("UpdateHostedDatabaseUser") {
+					t.Fatalf("expected the user to be updated through the v3 endpoints, got %v", f.calls)
+				}
+			},
+		},
+		{
+			name:    "update deleted elsewhere in a hosted cluster",
+			raw:     raw,
+			crud:    resourceCouchbaseCapellaDatabaseUserUpdate,
+			setup:   func(f *fakeCapellaClient) string { addHostedCluster(f); return "user" },
+			wantErr: "Failed to update",
+		},
+		{
+			name:  "delete",
+			raw:   raw,
+			crud:  resourceCouchbaseCapellaDatabaseUserDelete,
+			setup: addUser,
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if users := f.users["vpc-cluster-1"]; len(users) != 0 {
+					t.Fatalf("expected the user to be deleted, got %v", users)
+				}
+			},
+		},
+		{
+			name:  "delete in a hosted cluster",
+			raw:   raw,
+			crud:  resourceCouchbaseCapellaDatabaseUserDelete,
+			setup: addHostedUser,
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if users := f.users["vpc-cluster-1"]; len(users) != 0 {
+					t.Fatalf("expected the user to be deleted, got %v", users)
+				}
+			},
+		},
+		{
+			name:    "delete deleted elsewhere",
+			raw:     raw,
+			crud:    resourceCouchbaseCapellaDatabaseUserDelete,
+			setup:   func(f *fakeCapellaClient) string { addCluster(f); return "user" },
+			wantErr: "Failed to delete",
+		},
+	})
+}
+
+// Test to see if a database user of a hosted cluster is created with roles on single buckets,
+// one per entry of the request, moved to a role on every bucket and deleted through the v3
+// user endpoints
+func TestDatabaseUserCRUD_hostedCluster(t *testing.T) {
+	server, client := newTestMockClient(t)
+	clusterId := server.AddHostedCluster(server.AddProject("project"), "cluster", "aws", "us-west-2", "10.0.16.0/20")
+	server.AddBucket(clusterId, "bucket", 128)
+
+	specificAccess := map[string]interface{}{
+		"cluster_id": clusterId,
+		"username":   "user",
+		"password":   "Password123!",
+		"buckets": []interface{}{map[string]interface{}{
+			"bucket_name":   "bucket",
+			"bucket_access": []interface{}{"data_reader", "data_writer"},
+		}},
+	}
+	allAccess := map[string]interface{}{
+		"cluster_id":        clusterId,
+		"username":          "user",
+		"password":          "Password123!",
+		"all_bucket_access": "data_writer",
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	r := resourceCouchbaseCapellaDatabaseUser()
+
+	d := schema.TestResourceDataRaw(t, r.Schema, specificAccess)
+	if diags := resourceCouchbaseCapellaDatabaseUserCreate(ctx, d, client); diags.HasError() {
+		t.Fatalf("err: %v", diags)
+	}
+	users, _, err := client.ListHostedDatabaseUsers(ctx, clusterId)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(users) != 1 || users[0].GetUserId() == "" || len(users[0].Access) != 1 || users[0].Access[0].BucketName != "bucket" || len(users[0].Access[0].BucketAccess) != 2 {
+		t.Fatalf("expected the user to be created with both roles on the bucket, got %+v", users)
+	}
+
+	d = testResourceDataUpdate(t, r, "user", specificAccess, allAccess, client)
+	if diags := resourceCouchbaseCapellaDatabaseUserUpdate(ctx, d, client); diags.HasError() {
+		t.Fatalf("err: %v", diags)
+	}
+	if users, _, err := client.ListHostedDatabaseUsers(ctx, clusterId); err != nil || len(users) != 1 || len(users[0].Access) != 0 {
+		t.Fatalf("expected the user to lose its access to single buckets, got %+v: %v", users, err)
+	}
+
+	if diags := resourceCouchbaseCapellaDatabaseUserDelete(ctx, d, client); diags.HasError() {
+		t.Fatalf("err: %v", diags)
+	}
+	if users, _, err := client.ListHostedDatabaseUsers(ctx, clusterId); err != nil || len(users) != 0 {
+		t.Fatalf("expected the user to be deleted, got %+v: %v", users, err)
+	}
+}
+
+// Test to see if a database user of a hosted cluster is read when it is past the first page of the list of users
+func TestDatabaseUserRead_hostedClusterPages(t *testing.T) {
+	server, client := newTestMockClient(t)
+	clusterId := server.AddHostedCluster(server.AddProject("project"), "cluster", "aws", "us-west-2", "10.0.16.0/20")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for i := 0; i < 25; i++ {
+		request := *couchbasecapella.NewCreateDatabaseUserRequest(fmt.Sprintf("user-%02d", i), "Password123!")
+		request.SetAllBucketsAccess(couchbasecapella.BUCKETROLETYPES_READER)
+		if r, err := client.CreateHostedDatabaseUser(ctx, clusterId, request); err != nil {
+			t.Fatalf("err: %v", manageErrors(err, r, "Create Database User"))
+		}
+	}
+
+	users, _, err := client.ListHostedDatabaseUsers(ctx, clusterId)
+	if err != nil || len(users) != 25 {
+		t.Fatalf("expected every page of users to be listed, got %d users: %v", len(users), err)
+	}
+
+	d := schema.TestResourceDataRaw(t, resourceCouchbaseCapellaDatabaseUser().Schema, map[string]interface{}{
+		"cluster_id":        clusterId,
+		"username":          "user-24",
+		"password":          "Password123!",
+		"all_bucket_access": "data_reader",
+	})
+	d.SetId("user-24")
+	if diags := resourceCouchbaseCapellaDatabaseUserRead(ctx, d, client); diags.HasError() {
+		t.Fatalf("err: %v", diags)
+	}
+	if d.Id() != "user-24" {
+		t.Fatalf("expected the user on the last page to be read, got %q", d.Id())
+	}
+}
+
+// Test to see if database user has been destroyed after Terraform Destory has been executed
+func testAccCheckCouchbaseCapellaDatabaseUserDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*Client)
+	ctx := context.Background()
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "couchbasecapella_database_user" {
 			continue
 		}
 
-		users, _, err := client.ClustersApi.ClustersListUsers(auth, rs.Primary.Attributes["cluster_id"]).Execute()
+		clusterId := rs.Primary.Attributes["cluster_id"]
+		kind, diags := getClusterKind(ctx, client, clusterId)
+		if diags.HasError() {
+			return fmt.Errorf("%v", diags)
+		}
+		users, _, err := listDatabaseUsers(ctx, client, clusterId, kind)
 		if err != nil {
 			return fmt.Errorf("%v", err)
 		}
@@ -159,19 +374,8 @@ func testAccCheckCouchbaseCapellaDatabaseUserDestroy(s *terraform.State) error {
 // Test to see if database user exists after Terraform Apply has been executed
 func testAccCheckCouchbaseCapellaDatabaseUserExists(resourceName string, databaseUser *couchbasecapella.CreateDatabaseUserRequest) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
-		client := testAccProvider.Meta().(*couchbasecapella.APIClient)
-		auth := context.WithValue(
-			context.Background(),
-			couchbasecapella.ContextAPIKeys,
-			map[string]couchbasecapella.APIKey{
-				"accessKey": {
-					Key: os.Getenv("CBC_ACCESS_KEY"),
-				},
-				"secretKey": {
-					Key: os.Getenv("CBC_SECRET_KEY"),
-				},
-			},
-		)
+		client := testAccProvider.Meta().(*Client)
+		ctx := context.Background()
 
 		rs, ok := s.RootModule().Resources[resourceName]
 		if !ok {
@@ -182,7 +386,12 @@ func testAccCheckCouchbaseCapellaDatabaseUserExists(resourceName string, databas
 			return fmt.Errorf("no username is set")
 		}
 
-		users, _, err := client.ClustersApi.ClustersListUsers(auth, rs.Primary.Attributes["cluster_id"]).Execute()
+		clusterId := rs.Primary.Attributes["cluster_id"]
+		kind, diags := getClusterKind(ctx, client, clusterId)
+		if diags.HasError() {
+			return fmt.Errorf("%v", diags)
+		}
+		users, _, err := listDatabaseUsers(ctx, client, clusterId, kind)
 		if err != nil {
 			return fmt.Errorf("%v", err)
 		}
@@ -10,11 +10,15 @@ package provider
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
@@ -50,9 +54,10 @@ func resourceCouchbaseCapellaHostedCluster() *schema.Resource {
 				Optional:    true,
 			},
 			"project_id": {
-				Description:  "ID of the Project the Cluster is contained in",
+				Description:  "ID of the Project the Cluster is contained in. Defaults to the project_id set on the provider",
 				Type:         schema.TypeString,
-				Required:     true,
+				Optional:     true,
+				Computed:     true,
 				ValidateFunc: validation.IsUUID,
 			},
 			"place": {
@@ -121,7 +126,6 @@ func resourceCouchbaseCapellaHostedCluster() *schema.Resource {
 				Description: "Configuration of the servers in Cluster",
 				Type:        schema.TypeSet,
 				Required:    true,
-				ForceNew:    true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"size": {
@@ -137,10 +141,11 @@ func resourceCouchbaseCapellaHostedCluster() *schema.Resource {
 							ValidateFunc: validateCompute,
 						},
 						"services": {
-							Type:        schema.TypeList,
+							Type:        schema.TypeSet,
 							Description: "Couchbase Services",
 							Required:    true,
 							MinItems:    1,
+							Set:         schema.HashString,
 							Elem: &schema.Schema{
 								Type:         schema.TypeString,
 								ValidateFunc: validateService,
@@ -176,7 +181,52 @@ func resourceCouchbaseCapellaHostedCluster() *schema.Resource {
 					},
 				},
 			},
+			"status": {
+				Description: "Current status of the Cluster",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"server_version": {
+				Description: "Couchbase Server version running on the Cluster",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"endpoints_srv": {
+				Description: "DNS SRV record of the Cluster",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"connection_string": {
+				Description: "Connection string for the Couchbase SDKs",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"availability_zones": {
+				Description: "Availability zones the Cluster is deployed in",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"created_at": {
+				Description: "Time the Cluster was created, in RFC 3339 format",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"updated_at": {
+				Description: "Time the Cluster was last updated, in RFC 3339 format",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
 		},
+		CustomizeDiff: customdiff.All(
+			customizeDiffProjectID,
+			customizeDiffHostedServers,
+			customizeDiffHostedPlace,
+			customizeDiffHostedProvider,
+			customizeDiffServerTopology("couchbasecapella_hosted_cluster", maxHostedServerGroups),
+		),
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(25 * time.Minute),
 			Delete: schema.DefaultTimeout(25 * time.Minute),
@@ -185,11 +235,192 @@ func resourceCouchbaseCapellaHostedCluster() *schema.Resource {
 	}
 }
 
+// maxHostedServerGroups is the most server groups Capella deploys in a hosted cluster.
+const maxHostedServerGroups = 5
+
+// hostedStorageTypeRank orders the storage types of hosted clusters by performance,
+// so that a change to a storage type of a lower rank is a downgrade.
+var hostedStorageTypeRank = map[string]int{
+	string(couchbasecapella.V3STORAGETYPE_GP3):    0,
+	string(couchbasecapella.V3STORAGETYPE_PD_SSD): 0,
+	string(couchbasecapella.V3STORAGETYPE_IO2):    1,
+}
+
+// hostedStorage is the storage of a server group of a hosted cluster. The address
+// of the storage is only known for server groups read from the state.
+type hostedStorage struct {
+	address     string
+	storageType string
+	size        int
+}
+
+// customizeDiffHostedServers is responsible for replacing a hosted cluster when its
+// servers change in a way the Capella API can't apply in place. Changes to the size,
+// compute and services of the servers are scaled in place, but the storage of a server
+// group can neither be downgraded to a lower storage type nor shrunk.
+func customizeDiffHostedServers(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || !d.HasChange("servers") {
+		return nil
+	}
+	// The new servers are read from the configuration, which tells the values that
+	// are only known after apply apart.
+	config := d.GetRawConfig()
+	if config.IsNull() || !config.IsKnown() {
+		return nil
+	}
+	o, _ := d.GetChange("servers")
+	oldStorage := hostedServerStorage(o.(*schema.Set))
+	for key, storage := range hostedServerStorageConfig(config.GetAttr("servers")) {
+		old, ok := oldStorage[key]
+		if !ok {
+			continue
+		}
+		if storage.storageType != "" && storage.storageType != old.storageType &&
+			hostedStorageTypeRank[storage.storageType] < hostedStorageTypeRank[old.storageType] {
+			return d.ForceNew(old.address)
+		}
+		if storage.size != 0 && storage.size < old.size {
+			return d.ForceNew(old.address)
+		}
+	}
+	return nil
+}
+
+// customizeDiffHostedPlace is responsible for replacing a hosted cluster when its place
+// changes, as the Capella API can't move a cluster, and for rejecting a place that the
+// support package of the cluster doesn't allow. The configuration is read directly so
+// that values only known after apply are left alone.
+func customizeDiffHostedPlace(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if config := d.GetRawConfig(); !config.IsNull() && config.IsKnown() {
+		supportPackageType := ""
+		for _, supportPackage := range configElements(config.GetAttr("support_package")) {
+			if value := supportPackage.GetAttr("support_package_type"); value.IsKnown() && !value.IsNull() {
+				supportPackageType = value.AsString()
+			}
+		}
+		for _, place := range configElements(config.GetAttr("place")) {
+			singleAZ := place.GetAttr("single_az")
+			if supportPackageType == string(couchbasecapella.V3SUPPORTPACKAGETYPE_BASIC) &&
+				singleAZ.IsKnown() && !singleAZ.IsNull() && singleAZ.False() {
+				return fmt.Errorf(HostedClusterBasicSupportPackageMultiAZ)
+			}
+		}
+	}
+
+	if d.Id() == "" || !d.HasChange("place") {
+		return nil
+	}
+	o, _ := d.GetChange("place")
+	place := o.(*schema.Set)
+	for _, value := range place.List() {
+		if err := d.ForceNew(setElementAddress("place", place, value, "hosted")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// customizeDiffHostedProvider is responsible for validating the place and servers of a
+// hosted cluster against its cloud provider: the region and compute instances must exist
+// for the provider, the storage types must be supported by it, and the IOPS must be in the
+// range of the storage type. GCP storage takes no IOPS.
+func customizeDiffHostedProvider(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	config := d.GetRawConfig()
+	if config.IsNull() || !config.IsKnown() {
+		return nil
+	}
+
+	provider := ""
+	var errs *multierror.Error
+	for _, place := range configElements(config.GetAttr("place")) {
+		for _, hosted := range configElements(place.GetAttr("hosted")) {
+			provider = configString(hosted.GetAttr("provider"))
+			region := configString(hosted.GetAttr("region"))
+			if provider != "" && region != "" && !isValidRegion(provider, region) {
+				errs = multierror.Append(errs, fmt.Errorf(HostedClusterInvalidProviderRegion, provider, region))
+			}
+		}
+	}
+	if !couchbasecapella.V3Provider(provider).IsValid() {
+		return errs.ErrorOrNil()
+	}
+
+	for _, server := range configElements(config.GetAttr("servers")) {
+		group := configServerGroup(server)
+		if compute := configString(server.GetAttr("compute")); compute != "" && !isValidCompute(provider, compute) {
+			errs = multierror.Append(errs, fmt.Errorf(HostedClusterInvalidProviderCompute, group, provider, compute))
+		}
+		for _, storage := range configElements(server.GetAttr("storage")) {
+			storageType := configString(storage.GetAttr("storage_type"))
+			if storageTypes, ok := hostedStorageTypes[provider]; ok && storageType != "" && !Has(storageTypes, storageType) {
+				errs = multierror.Append(errs, fmt.Errorf(HostedClusterInvalidProviderStorageType, group, provider, strings.Join(storageTypes, ", "), storageType))
+			}
+
+			iops := storage.GetAttr("iops")
+			if iops.IsNull() || !iops.IsKnown() {
+				continue
+			}
+			value, _ := iops.AsBigFloat().Int64()
+			if provider == string(couchbasecapella.V3PROVIDER_GCP) {
+				errs = multierror.Append(errs, fmt.Errorf(HostedClusterGCPIOPS, group))
+			} else if iopsRange, ok := hostedIopsRanges[storageType]; ok && (int(value) < iopsRange[0] || int(value) > iopsRange[1]) {
+				errs = multierror.Append(errs, fmt.Errorf(HostedClusterInvalidStorageTypeIOPS, group, iopsRange[0], iopsRange[1], storageType, value))
+			}
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// hostedServerStorage is responsible for keying the storage of the server groups of a
+// hosted cluster by their combination of services, so that a group can be matched
+// across a change.
+func hostedServerStorage(servers *schema.Set) map[string]hostedStorage {
+	result := make(map[string]hostedStorage, servers.Len())
+	for _, value := range servers.List() {
+		server := value.(map[string]interface{})
+		var services []string
+		for _, service := range server["services"].(*schema.Set).List() {
+			if service, ok := service.(string); ok {
+				services = append(services, service)
+			}
+		}
+		for _, storage := range server["storage"].(*schema.Set).List() {
+			storage := storage.(map[string]interface{})
+			result[servicesKey(services)] = hostedStorage{
+				address:     setElementAddress("servers", servers, server, "storage"),
+				storageType: storage["storage_type"].(string),
+				size:        storage["storage_size"].(int),
+			}
+		}
+	}
+	return result
+}
+
+// hostedServerStorageConfig is responsible for keying the storage of the server groups
+// in the configuration of a hosted cluster by their combination of services. Server
+// groups whose services or storage aren't known yet are left out.
+func hostedServerStorageConfig(servers cty.Value) map[string]hostedStorage {
+	result := make(map[string]hostedStorage)
+	for _, server := range configElements(servers) {
+		if !server.GetAttr("services").IsWhollyKnown() {
+			continue
+		}
+		for _, storage := range configElements(server.GetAttr("storage")) {
+			s := hostedStorage{storageType: configString(storage.GetAttr("storage_type"))}
+			if size := storage.GetAttr("storage_size"); size.IsKnown() && !size.IsNull() {
+				value, _ := size.AsBigFloat().Int64()
+				s.size = int(value)
+			}
+			result[configServerGroup(server)] = s
+		}
+	}
+	return result
+}
+
 // resourceCouchbaseCapellaHostedClusterCreate is responsible for creating a
 // hosted cluster in Couchbase Capella using the Terraform resource data.
 func resourceCouchbaseCapellaHostedClusterCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*couchbasecapella.APIClient)
-	auth := getAuth(ctx)
+	client := meta.(capellaClient)
 
 	environment := "hosted"
 	clusterName := d.Get("name").(string)
@@ -198,11 +429,6 @@ func resourceCouchbaseCapellaHostedClusterCreate(ctx context.Context, d *schema.
 	supportPackage := expandHostedSupportPackageSet((d.Get("support_package")).(*schema.Set))
 	servers := expandHostedServersSet(d.Get("servers").(*schema.Set), place.Hosted.Provider)
 
-	// force Single AZ to true if support package is Basic
-	if supportPackage.Type == couchbasecapella.V3SUPPORTPACKAGETYPE_BASIC {
-		place.SingleAZ = true
-	}
-
 	newClusterRequest := *couchbasecapella.NewV3CreateClusterRequest(couchbasecapella.V3Environment(environment), clusterName, projectId,
 		place, servers, supportPackage)
 
@@ -212,33 +438,20 @@ func resourceCouchbaseCapellaHostedClusterCreate(ctx context.Context, d *schema.
 	}
 
 	// Create the cluster
-	response, err := client.ClustersV3Api.ClustersV3create(auth).V3CreateClusterRequest(newClusterRequest).Execute()
+	clusterId, response, err := client.CreateHostedCluster(ctx, newClusterRequest)
 	if err != nil {
-		return manageErrors(err, *response, "Create Hosted Cluster")
+		return manageErrors(err, response, "Create Hosted Cluster")
 	}
-
-	// TODO: need to be changed after cloud api fix!
-	location := string(response.Header.Get("Location"))
-	urlparts := strings.Split(location, "/")
-	clusterId := urlparts[len(urlparts)-1]
 	d.SetId(clusterId)
 
-	defer response.Body.Close()
-
 	// Wait for the cluster to deploy
 	createStateConf := &resource.StateChangeConf{
-		Pending: []string{"deploying"},
-		Target:  []string{"healthy"},
-		Refresh: func() (interface{}, string, error) {
-			statusResp, _, err := client.ClustersV3Api.ClustersV3status(auth, clusterId).Execute()
-			if err != nil {
-				return 0, "Error", err
-			}
-			return statusResp, string(statusResp.Status), nil
-		},
+		Pending:    []string{"deploying"},
+		Target:     []string{"healthy"},
+		Refresh:    hostedClusterStatusRefreshFunc(ctx, client, clusterId),
 		Timeout:    d.Timeout(schema.TimeoutCreate),
-		Delay:      2 * time.Minute,
-		MinTimeout: 30 * time.Second,
+		Delay:      hostedClusterPollDelay,
+		MinTimeout: clusterPollMinTimeout,
 	}
 	_, err = createStateConf.WaitForStateContext(ctx)
 	if err != nil {
@@ -251,27 +464,74 @@ func resourceCouchbaseCapellaHostedClusterCreate(ctx context.Context, d *schema.
 // resourceCouchbaseCapellaHostedClusterRead is responsible for reading a
 // hosted cluster in Couchbase Capella using the Terraform resource data.
 func resourceCouchbaseCapellaHostedClusterRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*couchbasecapella.APIClient)
-	auth := getAuth(ctx)
+	client := meta.(capellaClient)
 	clusterId := d.Get("id").(string)
 
-	cluster, resp, err := client.ClustersV3Api.ClustersV3show(auth, clusterId).Execute()
+	cluster, resp, err := client.GetHostedCluster(ctx, clusterId)
 
 	if err != nil {
 		if resp != nil && resp.StatusCode == http.StatusNotFound {
 			d.SetId("")
 			return nil
 		}
-		return diag.FromErr(err)
+		return manageErrors(err, resp, "Read Hosted Cluster")
+	}
+	if cluster.Id == "" {
+		return emptyResponse("Read Hosted Cluster")
 	}
 
 	if err := d.Set("name", cluster.Name); err != nil {
 		return diag.FromErr(err)
 	}
+	if err := d.Set("description", cluster.Description); err != nil {
+		return diag.FromErr(err)
+	}
 	if err := d.Set("project_id", cluster.ProjectId); err != nil {
 		return diag.FromErr(err)
 	}
-	if err := d.Set("servers", flattenServers(cluster.Servers)); err != nil {
+	if cluster.Place.Provider != "" {
+		if err := d.Set("place", flattenHostedPlace(cluster, d.Get("place").(*schema.Set))); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	if supportPackage := flattenHostedSupportPackage(cluster, d.Get("support_package").(*schema.Set)); supportPackage != nil {
+		if err := d.Set("support_package", supportPackage); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	if err := d.Set("servers", flattenServers(cluster.Servers, d.Get("servers").(*schema.Set))); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// The status is only read separately when the cluster details don't include it
+	status := cluster.Status
+	if status == "" {
+		statusResp, r, err := client.GetHostedClusterStatus(ctx, clusterId)
+		if err != nil {
+			return manageErrors(err, r, "Read Hosted Cluster")
+		}
+		status = string(statusResp.Status)
+	}
+	endpointsSrv := cluster.GetEndpointsSrv()
+	if err := d.Set("status", status); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("server_version", hostedClusterServerVersion(cluster.Version)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("endpoints_srv", endpointsSrv); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("connection_string", connectionString(endpointsSrv)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("availability_zones", cluster.AvailabilityZones); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("created_at", flattenTimestamp(cluster.CreatedAt)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("updated_at", flattenTimestamp(cluster.UpdatedAt)); err != nil {
 		return diag.FromErr(err)
 	}
 
@@ -281,8 +541,7 @@ func resourceCouchbaseCapellaHostedClusterRead(ctx context.Context, d *schema.Re
 // resourceCouchbaseCapellaHostedClusterUpdate is responsible for updating a
 // hosted cluster in Couchbase Capella using the Terraform resource data.
 func resourceCouchbaseCapellaHostedClusterUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*couchbasecapella.APIClient)
-	auth := getAuth(ctx)
+	client := meta.(capellaClient)
 
 	clusterId := d.Get("id").(string)
 
@@ -291,9 +550,9 @@ func resourceCouchbaseCapellaHostedClusterUpdate(ctx context.Context, d *schema.
 		v3UpdateClusterMetaRequest := *couchbasecapella.NewV3UpdateClusterMetaRequest()
 		v3UpdateClusterMetaRequest.SetName(d.Get("name").(string))
 		v3UpdateClusterMetaRequest.SetDescription((d.Get("description").(string)))
-		_, err := client.ClustersV3Api.ClustersV3updateMeta(auth, clusterId).V3UpdateClusterMetaRequest(v3UpdateClusterMetaRequest).Execute()
+		r, err := client.UpdateHostedClusterMeta(ctx, clusterId, v3UpdateClusterMetaRequest)
 		if err != nil {
-			return diag.FromErr(err)
+			return manageErrors(err, r, "Update Hosted Cluster")
 		}
 	}
 
@@ -307,9 +566,9 @@ func resourceCouchbaseCapellaHostedClusterUpdate(ctx context.Context, d *schema.
 		v3UpdateClusterSupportRequest := couchbasecapella.V3UpdateClusterSupportRequest{
 			SupportPackage: v3UpdateClusterSupportRequestSupportPackage,
 		}
-		_, err := client.ClustersV3Api.ClustersV3updateSupport(auth, clusterId).V3UpdateClusterSupportRequest(v3UpdateClusterSupportRequest).Execute()
+		r, err := client.UpdateHostedClusterSupport(ctx, clusterId, v3UpdateClusterSupportRequest)
 		if err != nil {
-			return diag.FromErr(err)
+			return manageErrors(err, r, "Update Hosted Cluster")
 		}
 	}
 
@@ -319,25 +578,19 @@ func resourceCouchbaseCapellaHostedClusterUpdate(ctx context.Context, d *schema.
 		provider := place.Hosted.Provider
 		servers := expandHostedServersSet(d.Get("servers").(*schema.Set), provider)
 		v3UpdateClusterServersRequest := *couchbasecapella.NewV3UpdateClusterServersRequest(servers) // V3UpdateClusterServersRequest |  (optional)
-		_, err := client.ClustersV3Api.ClustersV3updateServers(auth, clusterId).V3UpdateClusterServersRequest(v3UpdateClusterServersRequest).Execute()
+		r, err := client.UpdateHostedClusterServers(ctx, clusterId, v3UpdateClusterServersRequest)
 		if err != nil {
-			return diag.FromErr(err)
+			return manageErrors(err, r, "Update Hosted Cluster")
 		}
 
 		// Wait for the cluster to deploy
 		updateStateConf := &resource.StateChangeConf{
-			Pending: []string{"deploying", "scaling"},
-			Target:  []string{"healthy"},
-			Refresh: func() (interface{}, string, error) {
-				statusResp, _, err := client.ClustersV3Api.ClustersV3status(auth, clusterId).Execute()
-				if err != nil {
-					return 0, "Error", err
-				}
-				return statusResp, string(statusResp.Status), nil
-			},
+			Pending:    []string{"deploying", "scaling", "rebalancing"},
+			Target:     []string{"healthy"},
+			Refresh:    hostedClusterStatusRefreshFunc(ctx, client, clusterId),
 			Timeout:    d.Timeout(schema.TimeoutUpdate),
-			Delay:      2 * time.Minute,
-			MinTimeout: 30 * time.Second,
+			Delay:      hostedClusterPollDelay,
+			MinTimeout: clusterPollMinTimeout,
 		}
 		_, err = updateStateConf.WaitForStateContext(ctx)
 		if err != nil {
@@ -351,36 +604,35 @@ func resourceCouchbaseCapellaHostedClusterUpdate(ctx context.Context, d *schema.
 // resourceCouchbaseCapellaHostedClusterDelete is responsible for deleting a
 // hosted cluster in Couchbase Capella using the Terraform resource data.
 func resourceCouchbaseCapellaHostedClusterDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*couchbasecapella.APIClient)
-	auth := getAuth(ctx)
+	client := meta.(capellaClient)
 
 	clusterId := d.Get("id").(string)
 
 	// Check that Cluster is ready to be destroyed
-	statusResp, _, err := client.ClustersV3Api.ClustersV3status(auth, clusterId).Execute()
+	statusResp, r, err := client.GetHostedClusterStatus(ctx, clusterId)
 	if err != nil {
-		return diag.FromErr(err)
+		return manageErrors(err, r, "Delete Hosted Cluster")
+	}
+	if statusResp.Status == "" {
+		return emptyResponse("Delete Hosted Cluster")
 	}
 	if statusResp.Status != couchbasecapella.V3CLUSTERSTATUS_HEALTHY {
 		return diag.Errorf("Cluster is not ready to be deleted. Cluster Status: %s", statusResp.Status)
 	}
 
-	_, err2 := client.ClustersV3Api.ClustersV3delete(auth, clusterId).Execute()
+	r, err2 := client.DeleteHostedCluster(ctx, clusterId)
 	if err2 != nil {
-		return diag.FromErr(err2)
+		return manageErrors(err2, r, "Delete Hosted Cluster")
 	}
 
 	// Wait for the cluster to be destroyed
 	deleteStateConf := &resource.StateChangeConf{
-		Pending: []string{"rebalancing", "destroying"},
-		Target:  []string{""},
-		Refresh: func() (interface{}, string, error) {
-			statusResp, _, _ := client.ClustersV3Api.ClustersV3status(auth, clusterId).Execute()
-			return statusResp, string(statusResp.Status), nil
-		},
+		Pending:    []string{"rebalancing", "destroying"},
+		Target:     []string{""},
+		Refresh:    hostedClusterStatusRefreshFunc(ctx, client, clusterId),
 		Timeout:    d.Timeout(schema.TimeoutDelete),
-		Delay:      2 * time.Minute,
-		MinTimeout: 30 * time.Second,
+		Delay:      hostedClusterPollDelay,
+		MinTimeout: clusterPollMinTimeout,
 	}
 	_, err = deleteStateConf.WaitForStateContext(ctx)
 	if err != nil {
@@ -390,6 +642,27 @@ func resourceCouchbaseCapellaHostedClusterDelete(ctx context.Context, d *schema.
 	return nil
 }
 
+// hostedClusterStatusRefreshFunc is responsible for reading the status of a hosted
+// cluster while waiting for it to change. A cluster that is gone has an empty status,
+// and a status response without a body is treated like a cluster that can't be found
+// yet, so the wait carries on instead of taking the missing status for a deleted cluster.
+func hostedClusterStatusRefreshFunc(ctx context.Context, client capellaClient, clusterId string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		statusResp, resp, err := client.GetHostedClusterStatus(ctx, clusterId)
+		if err != nil {
+			// The status endpoint stops answering once the cluster is gone
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				return clusterId, "", nil
+			}
+			return nil, "", err
+		}
+		if statusResp.Status == "" {
+			return nil, "", nil
+		}
+		return statusResp, string(statusResp.Status), nil
+	}
+}
+
 // expandHostedServersSet is responsible for converting the servers set into
 // a slice of type V3Servers
 func expandHostedServersSet(servers *schema.Set, provider couchbasecapella.V3Provider) []couchbasecapella.V3Servers {
@@ -421,7 +694,7 @@ func createHostedServer(v map[string]interface{}, provider couchbasecapella.V3Pr
 			server = couchbasecapella.V3Servers{
 				Size:     int32(v["size"].(int)),
 				Compute:  v["compute"].(string),
-				Services: expandHostedServiceList(v["services"].([]interface{})),
+				Services: expandHostedServiceList(v["services"].(*schema.Set).List()),
 				Storage: couchbasecapella.V3ServersStorage{
 					Type: couchbasecapella.V3StorageType((storage["storage_type"].(string))),
 					IOPS: Int32(int32(storage["iops"].(int))),
@@ -432,7 +705,7 @@ func createHostedServer(v map[string]interface{}, provider couchbasecapella.V3Pr
 			server = couchbasecapella.V3Servers{
 				Size:     int32(v["size"].(int)),
 				Compute:  v["compute"].(string),
-				Services: expandHostedServiceList(v["services"].([]interface{})),
+				Services: expandHostedServiceList(v["services"].(*schema.Set).List()),
 				Storage: couchbasecapella.V3ServersStorage{
 					Type: couchbasecapella.V3StorageType((storage["storage_type"].(string))),
 					Size: int32(storage["storage_size"].(int)),
@@ -498,24 +771,120 @@ func createHostedSupportPackage(v map[string]interface{}) couchbasecapella.V3Sup
 	return supportPackage
 }
 
-func flattenServers(servers []couchbasecapella.V3ClusterServers) []interface{} {
-	if servers != nil {
-		servs := make([]interface{}, len(servers))
+// flattenHostedPlace is responsible for converting the place of a hosted cluster
+// into the place set. When the Capella API doesn't say whether the cluster is in
+// a single availability zone, the value already in the state is kept.
+func flattenHostedPlace(cluster hostedCluster, current *schema.Set) []interface{} {
+	singleAZ := false
+	for _, value := range current.List() {
+		singleAZ = value.(map[string]interface{})["single_az"].(bool)
+	}
+	if cluster.SingleAZ != nil {
+		singleAZ = *cluster.SingleAZ
+	}
+
+	return []interface{}{map[string]interface{}{
+		"single_az": singleAZ,
+		"hosted": []interface{}{map[string]interface{}{
+			"provider": cluster.Place.Provider,
+			"region":   cluster.Place.Region,
+			"cidr":     cluster.Place.CIDR,
+		}},
+	}}
+}
+
+// flattenHostedSupportPackage is responsible for converting the support package of a
+// hosted cluster into the support package set. When the Capella API only returns the
+// type of the support package, the timezone already in the state is kept. It returns
+// nil when the Capella API returns neither.
+func flattenHostedSupportPackage(cluster hostedCluster, current *schema.Set) []interface{} {
+	var timezone, supportPackageType string
+	for _, value := range current.List() {
+		timezone = value.(map[string]interface{})["timezone"].(string)
+	}
+
+	switch {
+	case cluster.SupportPackage != nil:
+		timezone, supportPackageType = cluster.SupportPackage.Timezone, cluster.SupportPackage.Type
+	case cluster.Support != "":
+		supportPackageType = cluster.Support
+	default:
+		return nil
+	}
+
+	return []interface{}{map[string]interface{}{
+		"timezone":             timezone,
+		"support_package_type": supportPackageType,
+	}}
+}
+
+// hostedClusterServerVersion is responsible for picking the Couchbase Server version
+// of a hosted cluster, preferring the server component over the version name.
+func hostedClusterServerVersion(version couchbasecapella.V3ClusterVersion) string {
+	if version.Components.CbServerVersion != "" {
+		return version.Components.CbServerVersion
+	}
+	return version.Name
+}
 
-		for i, server := range servers {
-			s := make(map[string]interface{})
+// connectionString is responsible for building the connection string the Couchbase
+// SDKs use from the DNS SRV record of a cluster. It returns an empty string when the
+// cluster has no endpoint yet.
+func connectionString(endpointsSrv string) string {
+	if endpointsSrv == "" {
+		return ""
+	}
+	return "couchbases://" + endpointsSrv
+}
 
-			s["size"] = server.Size
-			s["compute"] = server.Compute
-			s["services"] = server.Services
-			s["storage"] = flattenStorage(server.Storage)
-			servs[i] = s
+// flattenTimestamp is responsible for formatting a timestamp returned by the Capella
+// API in RFC 3339 format, leaving timestamps the API didn't return empty.
+func flattenTimestamp(timestamp time.Time) string {
+	if timestamp.IsZero() {
+		return ""
+	}
+	return timestamp.UTC().Format(time.RFC3339)
+}
+
+// flattenServers is responsible for converting the server groups of a hosted cluster
+// into the servers set. The server groups are matched with the ones already in the state
+// by their combination of services, so that an IOPS value the state leaves unset keeps
+// being unset rather than taking the default that Capella picked.
+func flattenServers(servers []couchbasecapella.V3ClusterServers, current *schema.Set) []interface{} {
+	unsetIops := make(map[string]bool)
+	if current != nil {
+		for _, value := range current.List() {
+			server := value.(map[string]interface{})
+			var services []string
+			for _, service := range server["services"].(*schema.Set).List() {
+				services = append(services, service.(string))
+			}
+			for _, storage := range server["storage"].(*schema.Set).List() {
+				unsetIops[servicesKey(services)] = storage.(map[string]interface{})["iops"].(int) == 0
+			}
 		}
+	}
 
-		return servs
+	servs := make([]interface{}, len(servers))
+	for i, server := range servers {
+		services := make([]interface{}, len(server.Services))
+		for j, service := range server.Services {
+			services[j] = service
+		}
+		storage := server.Storage
+		if unsetIops[servicesKey(server.Services)] {
+			storage.IOPS = 0
+		}
+
+		servs[i] = map[string]interface{}{
+			"size":     server.Size,
+			"compute":  server.Compute,
+			"services": services,
+			"storage":  flattenStorage(storage),
+		}
 	}
 
-	return make([]interface{}, 0)
+	return servs
 }
 
 func flattenStorage(storage couchbasecapella.V3ClusterStorage) []interface{} {
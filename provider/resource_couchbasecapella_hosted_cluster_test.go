@@ -11,12 +11,17 @@ package provider
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	couchbasecapella "github.com/couchbasecloud/couchbase-capella-api-go-client"
+	"github.com/couchbasecloud/terraform-provider-couchbasecapella/provider/internal/capellamock"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
@@ -25,7 +30,8 @@ import (
 // the name, support packages and server services have updated correctly.
 func TestAccCouchbaseCapellaAWSHostedCluster(t *testing.T) {
 	var (
-		cluster couchbasecapella.V3Cluster
+		cluster   couchbasecapella.V3Cluster
+		clusterId string
 	)
 
 	resourceName := "couchbasecapella_hosted_cluster.test"
@@ -47,6 +53,9 @@ func TestAccCouchbaseCapellaAWSHostedCluster(t *testing.T) {
 					testAccCheckCouchbaseCapellaHostedClusterExists(resourceName, &cluster),
 					resource.TestCheckResourceAttr(resourceName, "name", clusterName),
 					resource.TestCheckResourceAttr(resourceName, "support_package.0.support_package_type", supportPackageType),
+					resource.TestCheckResourceAttrSet(resourceName, "endpoints_srv"),
+					resource.TestCheckResourceAttrSet(resourceName, "server_version"),
+					testAccCheckResourceIdUnchanged(resourceName, &clusterId),
 				),
 			},
 			{
@@ -69,6 +78,7 @@ func TestAccCouchbaseCapellaAWSHostedCluster(t *testing.T) {
 				Config: testAccCouchbaseCapellaAWSHostedClusterConfig_withUpdatedServices(updatedClusterName, projectId, cidr, updatedSupportPackageType),
 				Check: resource.ComposeTestCheckFunc(
 					testAccCheckCouchbaseCapellaHostedClusterExists(resourceName, &cluster),
+					testAccCheckResourceIdUnchanged(resourceName, &clusterId),
 					resource.TestCheckResourceAttr(resourceName, "name", updatedClusterName),
 					resource.TestCheckResourceAttr(resourceName, "support_package.0.support_package_type", updatedSupportPackageType),
 				),
@@ -150,21 +160,541 @@ func TestAccCouchbaseCapellaGCPHostedCluster(t *testing.T) {
 	})
 }
 
-// Test to see if hosted cluster has been destroyed after Terraform Destroy has been executed
-func testAccCheckCouchbaseCapellaHostedClusterDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*couchbasecapella.APIClient)
-	auth := context.WithValue(
-		context.Background(),
-		couchbasecapella.ContextAPIKeys,
-		map[string]couchbasecapella.APIKey{
-			"accessKey": {
-				Key: os.Getenv("CBC_ACCESS_KEY"),
+// Test to see if creating a hosted cluster copes with the failures the Capella API can
+// return while the cluster deploys
+func TestHostedClusterCreate_faults(t *testing.T) {
+	testCases := []struct {
+		name    string
+		faults  []capellamock.Fault
+		stuck   bool
+		wantErr string
+	}{
+		{
+			name: "throttled status",
+			faults: []capellamock.Fault{
+				{Method: http.MethodGet, Path: "v3/clusters/*/status", Count: 2, Status: http.StatusTooManyRequests, RetryAfter: "0"},
+			},
+		},
+		{
+			name: "status server errors",
+			faults: []capellamock.Fault{
+				{Method: http.MethodGet, Path: "v3/clusters/*/status", Count: 2, Status: http.StatusServiceUnavailable},
 			},
-			"secretKey": {
-				Key: os.Getenv("CBC_SECRET_KEY"),
+		},
+		{
+			name: "empty status",
+			faults: []capellamock.Fault{
+				{Method: http.MethodGet, Path: "v3/clusters/*/status", Count: 2, EmptyBody: true},
 			},
 		},
-	)
+		{
+			name: "create server error",
+			faults: []capellamock.Fault{
+				{Method: http.MethodPost, Path: "v3/clusters", Count: 1, Status: http.StatusInternalServerError},
+			},
+			wantErr: "Create Hosted Cluster: " + string(ErrServer),
+		},
+		{
+			name:    "empty cluster",
+			faults:  []capellamock.Fault{{Method: http.MethodGet, Path: "v3/clusters/*", EmptyBody: true}},
+			wantErr: "Read Hosted Cluster: " + string(ErrEmptyResponse),
+		},
+		{
+			name:    "stuck deploying",
+			stuck:   true,
+			wantErr: "Error waiting for cluster",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server, client := newTestMockClient(t)
+			if tc.stuck {
+				server.DeployDuration = time.Hour
+			}
+			for _, fault := range tc.faults {
+				server.Inject(fault)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if tc.stuck {
+				ctx, cancel = context.WithTimeout(ctx, 500*time.Millisecond)
+				defer cancel()
+			}
+
+			d := schema.TestResourceDataRaw(t, resourceCouchbaseCapellaHostedCluster().Schema, testHostedClusterRaw(server.AddProject("project")))
+			diags := resourceCouchbaseCapellaHostedClusterCreate(ctx, d, client)
+
+			if tc.wantErr == "" {
+				if diags.HasError() {
+					t.Fatalf("err: %v", diags)
+				}
+				if d.Id() == "" || d.Get("name") != "cluster" {
+					t.Fatalf("expected the deployed cluster to be read, got %q", d.Get("name"))
+				}
+				return
+			}
+			if !diags.HasError() || !strings.HasPrefix(diags[0].Summary, tc.wantErr) {
+				t.Fatalf("expected an error starting with %q, got %v", tc.wantErr, diags)
+			}
+		})
+	}
+}
+
+// Test to see if the hosted cluster CRUD functions make the expected calls to the Capella API
+func TestHostedClusterCRUD(t *testing.T) {
+	addCluster := func(f *fakeCapellaClient, status couchbasecapella.V3ClusterStatus) string {
+		f.hostedClusters["hosted-cluster-1"] = hostedCluster{V3Cluster: couchbasecapella.V3Cluster{Id: "hosted-cluster-1", Name: "cluster", ProjectId: "project-1", Status: string(status)}}
+		return "hosted-cluster-1"
+	}
+
+	runCRUDTestCases(t, resourceCouchbaseCapellaHostedCluster(), []crudTestCase{
+		{
+			name: "create",
+			raw:  testHostedClusterRaw("project-1"),
+			crud: resourceCouchbaseCapellaHostedClusterCreate,
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if _, ok := f.hostedClusters[d.Id()]; !ok {
+					t.Fatalf("expected cluster %s to be created, got %v", d.Id(), f.hostedClusters)
+				}
+				if servers := d.Get("servers").(*schema.Set).List(); len(servers) != 1 || servers[0].(map[string]interface{})["compute"] != "m5.xlarge" {
+					t.Fatalf("expected the servers of the cluster to be read, got %v", servers)
+				}
+				if d.Get("status") != "healthy" || d.Get("server_version") != "7.1.1" {
+					t.Fatalf("expected the status and version of the cluster to be read, got %v", d.State())
+				}
+				if d.Get("endpoints_srv") != "cb.hosted-cluster-1.cloud.couchbase.com" || d.Get("connection_string") != "couchbases://cb.hosted-cluster-1.cloud.couchbase.com" {
+					t.Fatalf("expected the endpoints of the cluster to be read, got %v", d.State())
+				}
+				if d.Get("created_at") != "2022-08-01T12:00:00Z" || d.Get("updated_at") != "2022-08-01T12:30:00Z" {
+					t.Fatalf("expected the timestamps of the cluster to be read, got %v", d.State())
+				}
+				if zones := d.Get("availability_zones").([]interface{}); len(zones) != 1 || zones[0] != "us-west-2a" {
+					t.Fatalf("expected the availability zones of the cluster to be read, got %v", zones)
+				}
+			},
+		},
+		{
+			name: "create fails validation",
+			raw:  testHostedClusterRaw("project-1"),
+			crud: resourceCouchbaseCapellaHostedClusterCreate,
+			setup: func(f *fakeCapellaClient) string {
+				f.errs["CreateHostedCluster"] = http.StatusUnprocessableEntity
+				return ""
+			},
+			wantErr: "Create Hosted Cluster: " + string(ErrValidation),
+		},
+		{
+			name:  "read",
+			raw:   testHostedClusterRaw("project-1"),
+			crud:  resourceCouchbaseCapellaHostedClusterRead,
+			setup: func(f *fakeCapellaClient) string { return addCluster(f, couchbasecapella.V3CLUSTERSTATUS_HEALTHY) },
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if d.Get("name") != "cluster" || d.Get("project_id") != "project-1" {
+					t.Fatalf("expected the cluster to be read, got %v", d.State())
+				}
+			},
+		},
+		{
+			name:  "read status separately",
+			raw:   testHostedClusterRaw("project-1"),
+			crud:  resourceCouchbaseCapellaHostedClusterRead,
+			setup: func(f *fakeCapellaClient) string { return addCluster(f, "") },
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if !f.called("GetHostedClusterStatus") {
+					t.Fatalf("expected the status to be read from the status endpoint, got calls %v", f.calls)
+				}
+				if d.Get("endpoints_srv") != "" || d.Get("connection_string") != "" || d.Get("created_at") != "" {
+					t.Fatalf("expected the missing operational attributes to be left empty, got %v", d.State())
+				}
+			},
+		},
+		{
+			name:  "read deleted elsewhere",
+			raw:   testHostedClusterRaw("project-1"),
+			crud:  resourceCouchbaseCapellaHostedClusterRead,
+			setup: func(f *fakeCapellaClient) string { return "hosted-cluster-1" },
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if d.Id() != "" {
+					t.Fatalf("expected the cluster to be removed from the state, got %q", d.Id())
+				}
+			},
+		},
+		{
+			name:  "delete",
+			raw:   testHostedClusterRaw("project-1"),
+			crud:  resourceCouchbaseCapellaHostedClusterDelete,
+			setup: func(f *fakeCapellaClient) string { return addCluster(f, couchbasecapella.V3CLUSTERSTATUS_HEALTHY) },
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if len(f.hostedClusters) != 0 {
+					t.Fatalf("expected the cluster to be deleted, got %v", f.hostedClusters)
+				}
+			},
+		},
+		{
+			name:    "delete while scaling",
+			raw:     testHostedClusterRaw("project-1"),
+			crud:    resourceCouchbaseCapellaHostedClusterDelete,
+			setup:   func(f *fakeCapellaClient) string { return addCluster(f, couchbasecapella.V3CLUSTERSTATUS_SCALING) },
+			wantErr: "Cluster is not ready to be deleted",
+		},
+	})
+}
+
+// Test to see if changes made to a hosted cluster outside of Terraform are read into the state
+func TestHostedClusterRead_drift(t *testing.T) {
+	testCases := []struct {
+		name  string
+		drift func(c *hostedCluster)
+		key   string
+		want  interface{}
+	}{
+		{name: "description", drift: func(c *hostedCluster) { c.Description = "changed" }, key: "description", want: "changed"},
+		{name: "provider", drift: func(c *hostedCluster) { c.Place.Provider = "gcp" }, key: "place.hosted.provider", want: "gcp"},
+		{name: "region", drift: func(c *hostedCluster) { c.Place.Region = "us-east-1" }, key: "place.hosted.region", want: "us-east-1"},
+		{name: "cidr", drift: func(c *hostedCluster) { c.Place.CIDR = "10.1.0.0/20" }, key: "place.hosted.cidr", want: "10.1.0.0/20"},
+		{name: "single az", drift: func(c *hostedCluster) { *c.SingleAZ = false }, key: "place.single_az", want: false},
+		{name: "single az not returned", drift: func(c *hostedCluster) { c.SingleAZ = nil }, key: "place.single_az", want: true},
+		{name: "support package type", drift: func(c *hostedCluster) { c.SupportPackage.Type = "Enterprise" }, key: "support_package.support_package_type", want: "Enterprise"},
+		{name: "support package timezone", drift: func(c *hostedCluster) { c.SupportPackage.Timezone = "PT" }, key: "support_package.timezone", want: "PT"},
+		{
+			name:  "support package type only",
+			drift: func(c *hostedCluster) { c.SupportPackage, c.Support = nil, "DeveloperPro" },
+			key:   "support_package.support_package_type",
+			want:  "DeveloperPro",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeCapellaClient()
+			description := "cluster"
+			request := couchbasecapella.V3CreateClusterRequest{
+				ClusterName:    "cluster",
+				ProjectId:      "project-1",
+				Description:    &description,
+				Place:          expandHostedPlaceSet(schema.TestResourceDataRaw(t, resourceCouchbaseCapellaHostedCluster().Schema, testHostedClusterRaw("project-1")).Get("place").(*schema.Set)),
+				SupportPackage: couchbasecapella.V3SupportPackage{Timezone: "GMT", Type: "Basic"},
+			}
+			id, _, _ := f.CreateHostedCluster(context.Background(), request)
+			cluster := f.hostedClusters[id]
+			tc.drift(&cluster)
+			f.hostedClusters[id] = cluster
+
+			d := schema.TestResourceDataRaw(t, resourceCouchbaseCapellaHostedCluster().Schema, testHostedClusterRaw("project-1"))
+			d.SetId(id)
+			if diags := resourceCouchbaseCapellaHostedClusterRead(context.Background(), d, f); diags.HasError() {
+				t.Fatalf("err: %v", diags)
+			}
+			if got := testNestedSetValue(d, tc.key); got != tc.want {
+				t.Fatalf("expected %s to be %v, got %v", tc.key, tc.want, got)
+			}
+		})
+	}
+}
+
+// testNestedSetValue is responsible for reading an attribute nested in single
+// element sets, such as "place.hosted.region".
+func testNestedSetValue(d *schema.ResourceData, key string) interface{} {
+	path := strings.Split(key, ".")
+	value := d.Get(path[0])
+	for _, name := range path[1:] {
+		elems := value.(*schema.Set).List()
+		if len(elems) == 0 {
+			return nil
+		}
+		value = elems[0].(map[string]interface{})[name]
+	}
+	return value
+}
+
+// Test to see if updating a hosted cluster only calls the endpoints of the changed attributes
+func TestHostedClusterUpdate(t *testing.T) {
+	shortenPollIntervals(t)
+	renamed := testHostedClusterRaw("project-1")
+	renamed["name"] = "renamed"
+	scaled := testHostedClusterRaw("project-1")
+	scaled["servers"].([]interface{})[0].(map[string]interface{})["size"] = 5
+
+	testCases := []struct {
+		name      string
+		raw       map[string]interface{}
+		wantCalls []string
+	}{
+		{name: "name", raw: renamed, wantCalls: []string{"UpdateHostedClusterMeta", "GetHostedCluster"}},
+		{name: "servers", raw: scaled, wantCalls: []string{"UpdateHostedClusterServers", "GetHostedClusterStatus", "GetHostedCluster"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeCapellaClient()
+			id, _, _ := f.CreateHostedCluster(context.Background(), couchbasecapella.V3CreateClusterRequest{ClusterName: "cluster", ProjectId: "project-1"})
+			f.calls = nil
+
+			r := resourceCouchbaseCapellaHostedCluster()
+			d := testResourceDataUpdate(t, r, id, testHostedClusterRaw("project-1"), tc.raw, f)
+			if diags := resourceCouchbaseCapellaHostedClusterUpdate(context.Background(), d, f); diags.HasError() {
+				t.Fatalf("err: %v", diags)
+			}
+			if fmt.Sprint(f.calls) != fmt.Sprint(tc.wantCalls) {
+				t.Fatalf("expected the calls %v, got %v", tc.wantCalls, f.calls)
+			}
+		})
+	}
+}
+
+// Test to see if reading the servers of a hosted cluster doesn't report changes for the order of their
+// services or for an IOPS value that Capella defaulted
+func TestHostedClusterRead_servers(t *testing.T) {
+	raw := testHostedClusterRaw("project-1")
+	server := raw["servers"].([]interface{})[0].(map[string]interface{})
+	server["services"] = []interface{}{"data", "index", "query"}
+	delete(server["storage"].([]interface{})[0].(map[string]interface{}), "iops")
+
+	f := newFakeCapellaClient()
+	f.hostedClusters["hosted-cluster-1"] = hostedCluster{V3Cluster: couchbasecapella.V3Cluster{
+		Id:        "hosted-cluster-1",
+		Name:      "cluster",
+		ProjectId: "project-1",
+		Status:    string(couchbasecapella.V3CLUSTERSTATUS_HEALTHY),
+		Servers: []couchbasecapella.V3ClusterServers{{
+			Size:     3,
+			Compute:  "m5.xlarge",
+			Services: []string{"query", "data", "index"},
+			Storage:  couchbasecapella.V3ClusterStorage{Type: "GP3", IOPS: 3000, Size: 50},
+		}},
+	}}
+
+	r := resourceCouchbaseCapellaHostedCluster()
+	d := schema.TestResourceDataRaw(t, r.Schema, raw)
+	d.SetId("hosted-cluster-1")
+	want := d.Get("servers").(*schema.Set)
+	if diags := resourceCouchbaseCapellaHostedClusterRead(context.Background(), d, f); diags.HasError() {
+		t.Fatalf("err: %v", diags)
+	}
+	if got := d.Get("servers").(*schema.Set); !got.Equal(want) {
+		t.Fatalf("expected the servers to be unchanged, got %v, want %v", got.List(), want.List())
+	}
+
+	// Without the servers in the state, as when importing, the IOPS that Capella returns are read
+	d = schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{})
+	d.SetId("hosted-cluster-1")
+	if diags := resourceCouchbaseCapellaHostedClusterRead(context.Background(), d, f); diags.HasError() {
+		t.Fatalf("err: %v", diags)
+	}
+	if iops := testNestedSetValue(d, "servers.storage.iops"); iops != 3000 {
+		t.Fatalf("expected the IOPS to be read, got %v", iops)
+	}
+}
+
+// Test to see if changes to the servers of a hosted cluster only replace it when they can't be applied in place
+func TestHostedClusterDiff_servers(t *testing.T) {
+	withServer := func(change func(server, storage map[string]interface{})) map[string]interface{} {
+		raw := testHostedClusterRaw("project-1")
+		server := raw["servers"].([]interface{})[0].(map[string]interface{})
+		change(server, server["storage"].([]interface{})[0].(map[string]interface{}))
+		return raw
+	}
+	withStorage := func(storageType string, iops, size int) map[string]interface{} {
+		return withServer(func(server, storage map[string]interface{}) {
+			storage["storage_type"], storage["iops"], storage["storage_size"] = storageType, iops, size
+		})
+	}
+
+	testCases := []struct {
+		name        string
+		old, new    map[string]interface{}
+		wantReplace bool
+	}{
+		{name: "size", old: testHostedClusterRaw("project-1"), new: withServer(func(server, _ map[string]interface{}) { server["size"] = 5 })},
+		{name: "compute", old: testHostedClusterRaw("project-1"), new: withServer(func(server, _ map[string]interface{}) { server["compute"] = "m5.2xlarge" })},
+		{name: "services", old: testHostedClusterRaw("project-1"), new: withServer(func(server, _ map[string]interface{}) { server["services"] = []interface{}{"data", "index"} })},
+		{name: "storage size increase", old: testHostedClusterRaw("project-1"), new: withStorage("GP3", 3000, 100)},
+		{name: "storage type upgrade", old: testHostedClusterRaw("project-1"), new: withStorage("IO2", 3000, 50)},
+		{name: "storage type downgrade", old: withStorage("IO2", 3000, 50), new: testHostedClusterRaw("project-1"), wantReplace: true},
+		{name: "storage size decrease", old: withStorage("GP3", 3000, 100), new: testHostedClusterRaw("project-1"), wantReplace: true},
+	}
+
+	r := resourceCouchbaseCapellaHostedCluster()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, diff, err := testResourceDiff(t, r, "hosted-cluster-1", tc.old, tc.new, newFakeCapellaClient())
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if diff == nil || len(diff.Attributes) == 0 {
+				t.Fatal("expected the servers to change")
+			}
+			if diff.RequiresNew() != tc.wantReplace {
+				t.Fatalf("expected replacement to be %t, got %t", tc.wantReplace, diff.RequiresNew())
+			}
+		})
+	}
+}
+
+// Test to see if changes to the place of a hosted cluster replace it, and if a place the support package doesn't allow is rejected
+func TestHostedClusterDiff_place(t *testing.T) {
+	withPlace := func(supportPackageType string, singleAZ bool, region, cidr string) map[string]interface{} {
+		raw := testHostedClusterRaw("project-1")
+		place := raw["place"].([]interface{})[0].(map[string]interface{})
+		hosted := place["hosted"].([]interface{})[0].(map[string]interface{})
+		place["single_az"], hosted["region"], hosted["cidr"] = singleAZ, region, cidr
+		raw["support_package"].([]interface{})[0].(map[string]interface{})["support_package_type"] = supportPackageType
+		return raw
+	}
+
+	testCases := []struct {
+		name        string
+		old, new    map[string]interface{}
+		wantReplace bool
+		wantErr     string
+	}{
+		{name: "region", old: testHostedClusterRaw("project-1"), new: withPlace("Basic", true, "us-east-1", "10.0.16.0/20"), wantReplace: true},
+		{name: "cidr", old: testHostedClusterRaw("project-1"), new: withPlace("Basic", true, "us-west-2", "10.0.32.0/20"), wantReplace: true},
+		{name: "single az", old: withPlace("DeveloperPro", true, "us-west-2", "10.0.16.0/20"), new: withPlace("DeveloperPro", false, "us-west-2", "10.0.16.0/20"), wantReplace: true},
+		{name: "support package", old: testHostedClusterRaw("project-1"), new: withPlace("DeveloperPro", true, "us-west-2", "10.0.16.0/20")},
+		{name: "basic multi az on create", new: withPlace("Basic", false, "us-west-2", "10.0.16.0/20"), wantErr: HostedClusterBasicSupportPackageMultiAZ},
+		{name: "basic multi az on update", old: withPlace("DeveloperPro", false, "us-west-2", "10.0.16.0/20"), new: withPlace("Basic", false, "us-west-2", "10.0.16.0/20"), wantErr: HostedClusterBasicSupportPackageMultiAZ},
+	}
+
+	r := resourceCouchbaseCapellaHostedCluster()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, diff, err := testResourceDiff(t, r, "hosted-cluster-1", tc.old, tc.new, newFakeCapellaClient())
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected the error %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if diff.RequiresNew() != tc.wantReplace {
+				t.Fatalf("expected replacement to be %t, got %t", tc.wantReplace, diff.RequiresNew())
+			}
+		})
+	}
+}
+
+// Test to see if the place and servers of a hosted cluster are validated against its cloud provider
+func TestHostedClusterDiff_provider(t *testing.T) {
+	withCluster := func(provider, region, compute, storageType string, iops int) map[string]interface{} {
+		raw := testHostedClusterRaw("project-1")
+		hosted := raw["place"].([]interface{})[0].(map[string]interface{})["hosted"].([]interface{})[0].(map[string]interface{})
+		hosted["provider"], hosted["region"] = provider, region
+		server := raw["servers"].([]interface{})[0].(map[string]interface{})
+		server["compute"], server["services"] = compute, []interface{}{"index", "data"}
+		storage := server["storage"].([]interface{})[0].(map[string]interface{})
+		storage["storage_type"] = storageType
+		if iops == 0 {
+			delete(storage, "iops")
+		} else {
+			storage["iops"] = iops
+		}
+		return raw
+	}
+
+	testCases := []struct {
+		name     string
+		raw      map[string]interface{}
+		wantErrs []string
+	}{
+		{name: "aws", raw: withCluster("aws", "us-west-2", "m5.xlarge", "GP3", 3000)},
+		{name: "aws io2", raw: withCluster("aws", "us-west-2", "m5.xlarge", "IO2", 1000)},
+		{name: "gcp", raw: withCluster("gcp", "us-east1", "n2-standard-4", "PD-SSD", 0)},
+		{
+			name:     "region of another provider",
+			raw:      withCluster("aws", "us-east1", "m5.xlarge", "GP3", 3000),
+			wantErrs: []string{"place.hosted.region: expected a valid region for aws, got us-east1"},
+		},
+		{
+			name:     "compute of another provider",
+			raw:      withCluster("aws", "us-west-2", "n2-standard-4", "GP3", 3000),
+			wantErrs: []string{"servers.compute of the data,index server group: expected a valid compute instance for aws, got n2-standard-4"},
+		},
+		{
+			name:     "storage type of another provider",
+			raw:      withCluster("gcp", "us-east1", "n2-standard-4", "GP3", 0),
+			wantErrs: []string{"servers.storage.storage_type of the data,index server group: expected a valid storage type for gcp {PD-SSD}, got GP3"},
+		},
+		{
+			name:     "iops out of range for the storage type",
+			raw:      withCluster("aws", "us-west-2", "m5.xlarge", "GP3", 1000),
+			wantErrs: []string{"servers.storage.iops of the data,index server group: expected a value between 3000 and 16000 for GP3 storage, got 1000"},
+		},
+		{
+			name:     "iops on gcp",
+			raw:      withCluster("gcp", "us-east1", "n2-standard-4", "PD-SSD", 3000),
+			wantErrs: []string{"servers.storage.iops of the data,index server group: iops can't be set for gcp storage"},
+		},
+		{
+			name: "several errors",
+			raw:  withCluster("gcp", "us-west-2", "m5.xlarge", "PD-SSD", 0),
+			wantErrs: []string{
+				"place.hosted.region: expected a valid region for gcp, got us-west-2",
+				"servers.compute of the data,index server group: expected a valid compute instance for gcp, got m5.xlarge",
+			},
+		},
+	}
+
+	r := resourceCouchbaseCapellaHostedCluster()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := testResourceDiff(t, r, "", nil, tc.raw, newFakeCapellaClient())
+			if len(tc.wantErrs) == 0 {
+				if err != nil {
+					t.Fatalf("err: %s", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected the errors %q", tc.wantErrs)
+			}
+			for _, want := range tc.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Fatalf("expected the error %q, got %s", want, err)
+				}
+			}
+		})
+	}
+}
+
+// testHostedClusterRaw is the raw configuration of a hosted cluster used by the unit tests
+func testHostedClusterRaw(projectId string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":       "cluster",
+		"project_id": projectId,
+		"place": []interface{}{map[string]interface{}{
+			"single_az": true,
+			"hosted": []interface{}{map[string]interface{}{
+				"provider": "aws",
+				"region":   "us-west-2",
+				"cidr":     "10.0.16.0/20",
+			}},
+		}},
+		"support_package": []interface{}{map[string]interface{}{
+			"timezone":             "GMT",
+			"support_package_type": "Basic",
+		}},
+		"servers": []interface{}{map[string]interface{}{
+			"size":     3,
+			"compute":  "m5.xlarge",
+			"services": []interface{}{"data"},
+			"storage": []interface{}{map[string]interface{}{
+				"storage_type": "GP3",
+				"iops":         3000,
+				"storage_size": 50,
+			}},
+		}},
+	}
+}
+
+// Test to see if hosted cluster has been destroyed after Terraform Destroy has been executed
+func testAccCheckCouchbaseCapellaHostedClusterDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*Client)
+	auth := client.getAuth(context.Background())
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "couchbasecapella_hosted_cluster" {
@@ -183,19 +713,8 @@ func testAccCheckCouchbaseCapellaHostedClusterDestroy(s *terraform.State) error
 // Test to see if hosted cluster exists after Terraform Apply has been executed
 func testAccCheckCouchbaseCapellaHostedClusterExists(resourceName string, cluster *couchbasecapella.V3Cluster) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
-		client := testAccProvider.Meta().(*couchbasecapella.APIClient)
-		auth := context.WithValue(
-			context.Background(),
-			couchbasecapella.ContextAPIKeys,
-			map[string]couchbasecapella.APIKey{
-				"accessKey": {
-					Key: os.Getenv("CBC_ACCESS_KEY"),
-				},
-				"secretKey": {
-					Key: os.Getenv("CBC_SECRET_KEY"),
-				},
-			},
-		)
+		client := testAccProvider.Meta().(*Client)
+		auth := client.getAuth(context.Background())
 
 		rs, ok := s.RootModule().Resources[resourceName]
 		if !ok {
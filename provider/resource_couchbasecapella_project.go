@@ -48,18 +48,17 @@ func resourceCouchbaseCapellaProject() *schema.Resource {
 // resourceCouchbaseCapellaProjectCreate is responsible for creating a
 // project in Couchbase Capella using the Terraform resource data.
 func resourceCouchbaseCapellaProjectCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*couchbasecapella.APIClient)
-	auth := getAuth(ctx)
+	client := meta.(capellaClient)
 	projectName := d.Get("name").(string)
 
 	createProjectRequest := *couchbasecapella.NewCreateProjectRequest(projectName)
 
-	project, r, err := client.ProjectsApi.ProjectsCreate(auth).CreateProjectRequest(createProjectRequest).Execute()
-	if r == nil {
-		return diag.Errorf("Pointer to project create http.Response is nil")
-	}
+	project, r, err := client.CreateProject(ctx, createProjectRequest)
 	if err != nil {
-		return manageErrors(err, *r, "Create Project")
+		return manageErrors(err, r, "Create Project")
+	}
+	if project.Id == "" {
+		return emptyResponse("Create Project")
 	}
 
 	d.SetId(project.Id)
@@ -70,18 +69,17 @@ func resourceCouchbaseCapellaProjectCreate(ctx context.Context, d *schema.Resour
 // resourceCouchbaseCapellaProjectRead is responsible for reading a
 // project in Couchbase Capella using the Terraform resource data.
 func resourceCouchbaseCapellaProjectRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*couchbasecapella.APIClient)
-	auth := getAuth(ctx)
+	client := meta.(capellaClient)
 	projectId := d.Id()
 
-	_, resp, err := client.ProjectsApi.ProjectsShow(auth, projectId).Execute()
+	_, resp, err := client.GetProject(ctx, projectId)
 
 	if err != nil {
 		if resp != nil && resp.StatusCode == http.StatusNotFound {
 			d.SetId("")
 			return nil
 		}
-		return diag.FromErr(err)
+		return manageErrors(err, resp, "Read Project")
 	}
 
 	return nil
@@ -90,28 +88,23 @@ func resourceCouchbaseCapellaProjectRead(ctx context.Context, d *schema.Resource
 // resourceCouchbaseCapellaProjectDelete is responsible for deleting a
 // project in Couchbase Capella using the Terraform resource data.
 func resourceCouchbaseCapellaProjectDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*couchbasecapella.APIClient)
-	auth := getAuth(ctx)
+	client := meta.(capellaClient)
 
 	projectId := d.Id()
 
 	// Check to see if project exists in Capella. If the project
 	// exists, it will be deleted. If the project does not exist in Capella,
 	// likely being deleted elsewhere, an error is thrown.
-	_, _, err := client.ProjectsApi.ProjectsShow(auth, projectId).Execute()
+	_, _, err := client.GetProject(ctx, projectId)
 	if err != nil {
 		return diag.Errorf("Failed to delete: Project doesn't exist Capella")
 	}
-	r, err := client.ProjectsApi.ProjectsDelete(auth, projectId).Execute()
-	if r == nil {
-		return diag.Errorf("Pointer to project delete http.Response is nil")
-	}
-	if r.StatusCode == 400 {
-		return diag.Errorf(ProjectDeleteClustersStillAssociated)
-	}
-
+	r, err := client.DeleteProject(ctx, projectId)
 	if err != nil {
-		return manageErrors(err, *r, "Delete Project")
+		if r != nil && r.StatusCode == http.StatusBadRequest {
+			return diag.Errorf(ProjectDeleteClustersStillAssociated)
+		}
+		return manageErrors(err, r, "Delete Project")
 	}
 	return nil
 }
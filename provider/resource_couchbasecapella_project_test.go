@@ -11,12 +11,13 @@ package provider
 import (
 	"context"
 	"fmt"
-	"os"
+	"net/http"
 	"testing"
 
 	couchbasecapella "github.com/couchbasecloud/couchbase-capella-api-go-client"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
@@ -52,21 +53,88 @@ func TestAccCouchbaseCapellaProject(t *testing.T) {
 	})
 }
 
-// Test to see if project has been destroyed after Terraform Destory has been executed
-func testAccCheckCouchbaseCapellaProjectDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*couchbasecapella.APIClient)
-	auth := context.WithValue(
-		context.Background(),
-		couchbasecapella.ContextAPIKeys,
-		map[string]couchbasecapella.APIKey{
-			"accessKey": {
-				Key: os.Getenv("CBC_ACCESS_KEY"),
+// Test to see if the project CRUD functions make the expected calls to the Capella API
+func TestProjectCRUD(t *testing.T) {
+	addProject := func(f *fakeCapellaClient) string {
+		f.projects["project-1"] = couchbasecapella.Project{Id: "project-1", Name: "project"}
+		return "project-1"
+	}
+
+	runCRUDTestCases(t, resourceCouchbaseCapellaProject(), []crudTestCase{
+		{
+			name: "create",
+			raw:  map[string]interface{}{"name": "project"},
+			crud: resourceCouchbaseCapellaProjectCreate,
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if project, ok := f.projects[d.Id()]; !ok || project.Name != "project" {
+					t.Fatalf("expected project %s to be created, got %v", d.Id(), f.projects)
+				}
+			},
+		},
+		{
+			name:    "create fails",
+			raw:     map[string]interface{}{"name": "project"},
+			crud:    resourceCouchbaseCapellaProjectCreate,
+			setup:   func(f *fakeCapellaClient) string { f.errs["CreateProject"] = http.StatusInternalServerError; return "" },
+			wantErr: "Create Project: " + string(ErrServer),
+		},
+		{
+			name:  "read",
+			raw:   map[string]interface{}{"name": "project"},
+			crud:  resourceCouchbaseCapellaProjectRead,
+			setup: addProject,
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if d.Id() != "project-1" {
+					t.Fatalf("expected the project to be kept, got %q", d.Id())
+				}
 			},
-			"secretKey": {
-				Key: os.Getenv("CBC_SECRET_KEY"),
+		},
+		{
+			name:  "read deleted elsewhere",
+			raw:   map[string]interface{}{"name": "project"},
+			crud:  resourceCouchbaseCapellaProjectRead,
+			setup: func(f *fakeCapellaClient) string { return "project-1" },
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if d.Id() != "" {
+					t.Fatalf("expected the project to be removed from the state, got %q", d.Id())
+				}
 			},
 		},
-	)
+		{
+			name:  "delete",
+			raw:   map[string]interface{}{"name": "project"},
+			crud:  resourceCouchbaseCapellaProjectDelete,
+			setup: addProject,
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if len(f.projects) != 0 {
+					t.Fatalf("expected the project to be deleted, got %v", f.projects)
+				}
+			},
+		},
+		{
+			name: "delete with clusters",
+			raw:  map[string]interface{}{"name": "project"},
+			crud: resourceCouchbaseCapellaProjectDelete,
+			setup: func(f *fakeCapellaClient) string {
+				f.errs["DeleteProject"] = http.StatusBadRequest
+				return addProject(f)
+			},
+			wantErr: ProjectDeleteClustersStillAssociated,
+		},
+		{
+			name:    "delete deleted elsewhere",
+			raw:     map[string]interface{}{"name": "project"},
+			crud:    resourceCouchbaseCapellaProjectDelete,
+			setup:   func(f *fakeCapellaClient) string { return "project-1" },
+			wantErr: "Failed to delete",
+		},
+	})
+}
+
+// Test to see if project has been destroyed after Terraform Destory has been executed
+func testAccCheckCouchbaseCapellaProjectDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*Client)
+	auth := client.getAuth(context.Background())
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "couchbasecapella_project" {
@@ -85,19 +153,8 @@ func testAccCheckCouchbaseCapellaProjectDestroy(s *terraform.State) error {
 // Test to see if project exists after Terraform Apply has been executed
 func testAccCheckCouchbaseCapellaProjectExists(resourceName string, project *couchbasecapella.Project) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
-		client := testAccProvider.Meta().(*couchbasecapella.APIClient)
-		auth := context.WithValue(
-			context.Background(),
-			couchbasecapella.ContextAPIKeys,
-			map[string]couchbasecapella.APIKey{
-				"accessKey": {
-					Key: os.Getenv("CBC_ACCESS_KEY"),
-				},
-				"secretKey": {
-					Key: os.Getenv("CBC_SECRET_KEY"),
-				},
-			},
-		)
+		client := testAccProvider.Meta().(*Client)
+		auth := client.getAuth(context.Background())
 
 		rs, ok := s.RootModule().Resources[resourceName]
 		if !ok {
@@ -12,10 +12,13 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
@@ -29,6 +32,7 @@ func resourceCouchbaseCapellaVpcCluster() *schema.Resource {
 
 		CreateContext: resourceCouchbaseCapellaVpcClusterCreate,
 		ReadContext:   resourceCouchbaseCapellaVpcClusterRead,
+		UpdateContext: resourceCouchbaseCapellaVpcClusterUpdate,
 		DeleteContext: resourceCouchbaseCapellaVpcClusterDelete,
 
 		Schema: map[string]*schema.Schema{
@@ -53,9 +57,10 @@ func resourceCouchbaseCapellaVpcCluster() *schema.Resource {
 				ValidateFunc: validation.IsUUID,
 			},
 			"project_id": {
-				Description:  "ID of the Project",
+				Description:  "ID of the Project. Defaults to the project_id set on the provider",
 				Type:         schema.TypeString,
-				Required:     true,
+				Optional:     true,
+				Computed:     true,
 				ForceNew:     true,
 				ValidateFunc: validation.IsUUID,
 			},
@@ -63,7 +68,6 @@ func resourceCouchbaseCapellaVpcCluster() *schema.Resource {
 				Description: "Server Configuration of the Cluster",
 				Type:        schema.TypeSet,
 				Required:    true,
-				ForceNew:    true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"size": {
@@ -73,10 +77,11 @@ func resourceCouchbaseCapellaVpcCluster() *schema.Resource {
 							ValidateFunc: validateSize,
 						},
 						"services": {
-							Type:        schema.TypeList,
+							Type:        schema.TypeSet,
 							Description: "Couchbase Services",
 							Required:    true,
 							MinItems:    1,
+							Set:         schema.HashString,
 							Elem: &schema.Schema{
 								Type:         schema.TypeString,
 								ValidateFunc: validateService,
@@ -124,79 +129,239 @@ func resourceCouchbaseCapellaVpcCluster() *schema.Resource {
 								},
 							},
 						},
+						"gcp": {
+							Description: "Gcp configuration",
+							Type:        schema.TypeSet,
+							Optional:    true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"instance_size": {
+										Description:  "Gcp machine type",
+										Type:         schema.TypeString,
+										Required:     true,
+										ValidateFunc: validateGcpInstance,
+									},
+									"disk_size_gib": {
+										Description:  "Gcp persistent disk size (Gb)",
+										Type:         schema.TypeInt,
+										Required:     true,
+										ValidateFunc: validateGcpDiskSize,
+									},
+								},
+							},
+						},
 					},
 				},
 			},
+			"status": {
+				Description: "Current status of the Cluster",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"server_version": {
+				Description: "Couchbase Server version running on the Cluster",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"endpoints_url": {
+				Description: "URLs of the public endpoints of the Cluster",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"endpoints_srv": {
+				Description: "DNS SRV record of the Cluster",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"private_endpoints_srv": {
+				Description: "DNS SRV record of the private endpoint of the Cluster",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"connection_string": {
+				Description: "Connection string for the Couchbase SDKs",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
 		},
+		CustomizeDiff: customdiff.All(
+			customizeDiffProjectID,
+			customizeDiffVpcServers,
+			customizeDiffServerTopology("couchbasecapella_vpc_cluster", maxVpcServerGroups),
+		),
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(25 * time.Minute),
 			Delete: schema.DefaultTimeout(25 * time.Minute),
+			Update: schema.DefaultTimeout(25 * time.Minute),
 		},
 	}
 }
 
+// maxVpcServerGroups is the most server groups Capella deploys in a vpc cluster.
+const maxVpcServerGroups = 5
+
+// vpcVolume is the volume of the servers of a server group of a vpc cluster. Its size
+// is the size of the EBS volume or persistent disk in GiB on AWS and GCP, and the tier
+// of the disk on Azure, e.g. 6 for P6, which grows with the size of the disk. The
+// address of the volume is only known for server groups read from the state.
+type vpcVolume struct {
+	address  string
+	provider string
+	size     int
+}
+
+// customizeDiffVpcServers is responsible for replacing a vpc cluster when its servers
+// change in a way the Capella API can't apply in place. The number of nodes, instance
+// sizes and volumes of the server groups are scaled in place, but a volume can't shrink.
+func customizeDiffVpcServers(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || !d.HasChange("servers") {
+		return nil
+	}
+	// The new servers are read from the configuration, which tells the values that
+	// are only known after apply apart.
+	config := d.GetRawConfig()
+	if config.IsNull() || !config.IsKnown() {
+		return nil
+	}
+	o, _ := d.GetChange("servers")
+	oldVolumes := vpcServerVolumes(o.(*schema.Set))
+	for key, volume := range vpcServerVolumesConfig(config.GetAttr("servers")) {
+		old, ok := oldVolumes[key]
+		if !ok || volume.provider != old.provider {
+			continue
+		}
+		if volume.size != 0 && volume.size < old.size {
+			return d.ForceNew(old.address)
+		}
+	}
+	return nil
+}
+
+// vpcServerVolumes is responsible for keying the volumes of the server groups of a
+// vpc cluster by their combination of services.
+func vpcServerVolumes(servers *schema.Set) map[string]vpcVolume {
+	result := make(map[string]vpcVolume, servers.Len())
+	for _, value := range servers.List() {
+		server := value.(map[string]interface{})
+		var services []string
+		for _, service := range server["services"].(*schema.Set).List() {
+			if service, ok := service.(string); ok {
+				services = append(services, service)
+			}
+		}
+		for _, aws := range server["aws"].(*schema.Set).List() {
+			result[servicesKey(services)] = vpcVolume{
+				address:  setElementAddress("servers", servers, server, "aws"),
+				provider: "aws",
+				size:     aws.(map[string]interface{})["ebs_size_gib"].(int),
+			}
+		}
+		for _, azure := range server["azure"].(*schema.Set).List() {
+			result[servicesKey(services)] = vpcVolume{
+				address:  setElementAddress("servers", servers, server, "azure"),
+				provider: "azure",
+				size:     azureVolumeTier(azure.(map[string]interface{})["volume_type"].(string)),
+			}
+		}
+		for _, gcp := range server["gcp"].(*schema.Set).List() {
+			result[servicesKey(services)] = vpcVolume{
+				address:  setElementAddress("servers", servers, server, "gcp"),
+				provider: "gcp",
+				size:     gcp.(map[string]interface{})["disk_size_gib"].(int),
+			}
+		}
+	}
+	return result
+}
+
+// vpcServerVolumesConfig is responsible for keying the volumes of the server groups
+// in the configuration of a vpc cluster by their combination of services. Server
+// groups whose services aren't known yet are left out, and volumes that aren't known
+// yet have no size.
+func vpcServerVolumesConfig(servers cty.Value) map[string]vpcVolume {
+	result := make(map[string]vpcVolume)
+	for _, server := range configElements(servers) {
+		if !server.GetAttr("services").IsWhollyKnown() {
+			continue
+		}
+		for _, aws := range configElements(server.GetAttr("aws")) {
+			result[configServerGroup(server)] = vpcVolume{provider: "aws", size: configInt(aws.GetAttr("ebs_size_gib"))}
+		}
+		for _, gcp := range configElements(server.GetAttr("gcp")) {
+			result[configServerGroup(server)] = vpcVolume{provider: "gcp", size: configInt(gcp.GetAttr("disk_size_gib"))}
+		}
+		for _, azure := range configElements(server.GetAttr("azure")) {
+			result[configServerGroup(server)] = vpcVolume{
+				provider: "azure",
+				size:     azureVolumeTier(configString(azure.GetAttr("volume_type"))),
+			}
+		}
+	}
+	return result
+}
+
+// azureVolumeTier is responsible for returning the tier of an Azure disk, e.g. 6 for
+// P6, or 0 when the volume type isn't known.
+func azureVolumeTier(volumeType string) int {
+	tier, err := strconv.Atoi(strings.TrimPrefix(volumeType, "P"))
+	if err != nil {
+		return 0
+	}
+	return tier
+}
+
 // resourceCouchbaseCapellaVpcClusterCreate is responsible for creating a
 // vpc cluster in Couchbase Capella using the Terraform resource data.
 func resourceCouchbaseCapellaVpcClusterCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*couchbasecapella.APIClient)
-	auth := getAuth(ctx)
+	client := meta.(capellaClient)
 
 	clusterName := d.Get("name").(string)
 	cloudId := d.Get("cloud_id").(string)
 	projectId := d.Get("project_id").(string)
 
-	newClusterRequest := *couchbasecapella.NewCreateClusterRequest(clusterName, cloudId, projectId)
+	newClusterRequest := vpcClusterRequest{Name: clusterName, CloudId: cloudId, ProjectId: projectId}
 
 	// Get The cloud
-	cloud, resp, err := client.CloudsApi.CloudsShow(auth, cloudId).Execute()
+	cloud, resp, err := client.GetCloud(ctx, cloudId)
 	if err != nil {
 		if resp != nil && resp.StatusCode == http.StatusNotFound {
-			return diag.FromErr(fmt.Errorf("404: the cloud doesn't exist. Please verify your cloud_id"))
+			return diag.Diagnostics{{
+				Severity:      diag.Error,
+				Summary:       "404: the cloud doesn't exist. Please verify your cloud_id",
+				AttributePath: cty.GetAttrPath("cloud_id"),
+			}}
 		}
-		return diag.FromErr(err)
+		return manageErrors(err, resp, "Create VPC Cluster")
+	}
+	if cloud.Provider == "" {
+		return emptyResponse("Create VPC Cluster")
 	}
-	providerName := string(cloud.Provider)
 	// add Servers + Check servers Vs Cloud provider
 	if servers, ok := d.GetOk("servers"); ok {
-		// check server providers
-		providers := getVpcServersProvider(servers.(*schema.Set))
-		if len(providers) > 1 {
-			return diag.FromErr(fmt.Errorf(VpcClusterServerDoesNotMatchProvider))
+		if err := checkVpcServersProvider(servers.(*schema.Set), string(cloud.Provider)); err != nil {
+			return diag.FromErr(err)
 		}
-		if len(providers) == 1 && !Has(providers, providerName) {
-			return diag.FromErr(fmt.Errorf(VpcClusterServerDoesNotMatchProvider))
-		}
-		newClusterRequest.SetServers(expandVpcServersSet(servers.(*schema.Set)))
+		newClusterRequest.Servers = expandVpcServersSet(servers.(*schema.Set))
 	}
 
 	// Create the cluster
-	response, err := client.ClustersApi.ClustersCreate(auth).CreateClusterRequest(newClusterRequest).Execute()
+	clusterId, response, err := client.CreateVpcCluster(ctx, newClusterRequest)
 	if err != nil {
-		return manageErrors(err, *response, "Create Cluster")
+		return manageErrors(err, response, "Create VPC Cluster")
 	}
-
-	// TODO: need to be changed after cloud api fix!
-	location := string(response.Header.Get("Location"))
-	urlparts := strings.Split(location, "/")
-	clusterId := urlparts[len(urlparts)-1]
 	d.SetId(clusterId)
 
-	defer response.Body.Close()
-
 	// Wait for the cluster to deploy
 	createStateConf := &resource.StateChangeConf{
-		Pending: []string{"deploying", "deploy_succeeded"},
-		Target:  []string{"ready"},
-		Refresh: func() (interface{}, string, error) {
-			statusResp, _, err := client.ClustersApi.ClustersStatus(auth, clusterId).Execute()
-			if err != nil {
-				return 0, "Error", err
-			}
-			return statusResp, string(statusResp.Status), nil
-		},
+		Pending:    vpcClusterDeployingStatuses,
+		Target:     []string{"ready"},
+		Refresh:    vpcClusterStatusRefreshFunc(ctx, client, clusterId),
 		Timeout:    d.Timeout(schema.TimeoutCreate),
-		Delay:      5 * time.Minute,
-		MinTimeout: 30 * time.Second,
+		Delay:      vpcClusterPollDelay,
+		MinTimeout: clusterPollMinTimeout,
 	}
 	_, err = createStateConf.WaitForStateContext(ctx)
 	if err != nil {
@@ -209,56 +374,135 @@ func resourceCouchbaseCapellaVpcClusterCreate(ctx context.Context, d *schema.Res
 // resourceCouchbaseCapellaVpcClusterRead is responsible for reading a
 // vpc cluster in Couchbase Capella using the Terraform resource data.
 func resourceCouchbaseCapellaVpcClusterRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*couchbasecapella.APIClient)
-	auth := getAuth(ctx)
+	client := meta.(capellaClient)
 	clusterId := d.Id()
 
-	_, resp, err := client.ClustersApi.ClustersShow(auth, clusterId).Execute()
+	cluster, resp, err := client.GetVpcCluster(ctx, clusterId)
 
 	if err != nil {
 		if resp != nil && resp.StatusCode == http.StatusNotFound {
 			d.SetId("")
 			return nil
 		}
+		return manageErrors(err, resp, "Read VPC Cluster")
+	}
+	if cluster.Id == "" {
+		return emptyResponse("Read VPC Cluster")
+	}
+
+	if err := d.Set("name", cluster.Name); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("cloud_id", cluster.CloudId); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("project_id", cluster.ProjectId); err != nil {
+		return diag.FromErr(err)
+	}
+	// Older responses leave the server groups out, in which case the ones in the state are kept
+	if cluster.Servers != nil {
+		if err := d.Set("servers", flattenVpcServers(cluster.Servers)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	endpointsSrv := cluster.GetEndpointsSrv()
+	if err := d.Set("status", string(cluster.Status)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("server_version", vpcClusterServerVersion(cluster.Version)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("endpoints_url", cluster.GetEndpointsURL()); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("endpoints_srv", endpointsSrv); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("private_endpoints_srv", cluster.GetPrivateEndpointsSrv()); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("connection_string", connectionString(endpointsSrv)); err != nil {
 		return diag.FromErr(err)
 	}
 
 	return nil
 }
 
+// resourceCouchbaseCapellaVpcClusterUpdate is responsible for updating a
+// vpc cluster in Couchbase Capella using the Terraform resource data.
+func resourceCouchbaseCapellaVpcClusterUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(capellaClient)
+	clusterId := d.Id()
+
+	if d.HasChange("servers") {
+		cloudId := d.Get("cloud_id").(string)
+		cloud, r, err := client.GetCloud(ctx, cloudId)
+		if err != nil {
+			return manageErrors(err, r, "Update VPC Cluster")
+		}
+		if cloud.Provider == "" {
+			return emptyResponse("Update VPC Cluster")
+		}
+		servers := d.Get("servers").(*schema.Set)
+		if err := checkVpcServersProvider(servers, string(cloud.Provider)); err != nil {
+			return diag.FromErr(err)
+		}
+		r, err = client.UpdateVpcClusterServers(ctx, clusterId, expandVpcServersSet(servers))
+		if err != nil {
+			return manageErrors(err, r, "Update VPC Cluster")
+		}
+
+		// Wait for the cluster to scale
+		updateStateConf := &resource.StateChangeConf{
+			Pending:    vpcClusterDeployingStatuses,
+			Target:     []string{"ready"},
+			Refresh:    vpcClusterStatusRefreshFunc(ctx, client, clusterId),
+			Timeout:    d.Timeout(schema.TimeoutUpdate),
+			Delay:      vpcClusterPollDelay,
+			MinTimeout: clusterPollMinTimeout,
+		}
+		_, err = updateStateConf.WaitForStateContext(ctx)
+		if err != nil {
+			return diag.Errorf("Error waiting for vpc cluster (%s) to be updated: %s", d.Id(), err)
+		}
+	}
+
+	return resourceCouchbaseCapellaVpcClusterRead(ctx, d, meta)
+}
+
 // resourceCouchbaseCapellaVpcClusterDelete is responsible for deleting a
 // vpc cluster in Couchbase Capella using the Terraform resource data.
 func resourceCouchbaseCapellaVpcClusterDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*couchbasecapella.APIClient)
-	auth := getAuth(ctx)
+	client := meta.(capellaClient)
 
 	clusterId := d.Id()
 
 	// Check that Cluster is ready to be destroyed
-	statusResp, _, err := client.ClustersApi.ClustersStatus(auth, clusterId).Execute()
+	statusResp, resp, err := client.GetVpcClusterStatus(ctx, clusterId)
 	if err != nil {
-		return diag.FromErr(err)
+		return manageErrors(err, resp, "Delete VPC Cluster")
+	}
+	if statusResp.Status == "" {
+		return emptyResponse("Delete VPC Cluster")
 	}
 	if statusResp.Status != couchbasecapella.CLUSTERSTATUS_READY {
 		return diag.Errorf("VPC Cluster is not ready to be deleted. Cluster Status: %s", statusResp.Status)
 	}
 
-	r, err2 := client.ClustersApi.ClustersDelete(auth, clusterId).Execute()
+	r, err2 := client.DeleteVpcCluster(ctx, clusterId)
 	if err2 != nil {
-		return manageErrors(err2, *r, "VPC Cluster Delete")
+		return manageErrors(err2, r, "Delete VPC Cluster")
 	}
 
 	// Wait for the cluster to be destroyed
 	deleteStateConf := &resource.StateChangeConf{
-		Pending: []string{"destroying", "destroy_succeeded"},
-		Target:  []string{""},
-		Refresh: func() (interface{}, string, error) {
-			statusResp, _, _ := client.ClustersApi.ClustersStatus(auth, clusterId).Execute()
-			return statusResp, string(statusResp.Status), nil
-		},
+		Pending:    []string{"destroying", "destroy_succeeded"},
+		Target:     []string{""},
+		Refresh:    vpcClusterStatusRefreshFunc(ctx, client, clusterId),
 		Timeout:    d.Timeout(schema.TimeoutDelete),
-		Delay:      5 * time.Minute,
-		MinTimeout: 5 * time.Second,
+		Delay:      vpcClusterPollDelay,
+		MinTimeout: vpcClusterDeletePollMinTimeout,
 	}
 	_, err = deleteStateConf.WaitForStateContext(ctx)
 	if err != nil {
@@ -268,10 +512,42 @@ func resourceCouchbaseCapellaVpcClusterDelete(ctx context.Context, d *schema.Res
 	return nil
 }
 
+// vpcClusterDeployingStatuses are the statuses a vpc cluster goes through while
+// Capella deploys it or scales its servers, before it is ready again.
+var vpcClusterDeployingStatuses = []string{
+	string(couchbasecapella.CLUSTERSTATUS_NEEDS_DEPLOY),
+	string(couchbasecapella.CLUSTERSTATUS_JOB_SCHEDULED),
+	string(couchbasecapella.CLUSTERSTATUS_PREFLIGHT_STARTED),
+	string(couchbasecapella.CLUSTERSTATUS_PREFLIGHT_SUCCEEDED),
+	string(couchbasecapella.CLUSTERSTATUS_DEPLOYING),
+	string(couchbasecapella.CLUSTERSTATUS_DEPLOY_SUCCEEDED),
+}
+
+// vpcClusterStatusRefreshFunc is responsible for reading the status of a vpc
+// cluster while waiting for it to change. A cluster that is gone has an empty status,
+// and a status response without a body is treated like a cluster that can't be found
+// yet, so the wait carries on instead of taking the missing status for a deleted cluster.
+func vpcClusterStatusRefreshFunc(ctx context.Context, client capellaClient, clusterId string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		statusResp, resp, err := client.GetVpcClusterStatus(ctx, clusterId)
+		if err != nil {
+			// The status endpoint stops answering once the cluster is gone
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				return clusterId, "", nil
+			}
+			return nil, "", err
+		}
+		if statusResp.Status == "" {
+			return nil, "", nil
+		}
+		return statusResp, string(statusResp.Status), nil
+	}
+}
+
 // expandVpcServersSet is responsible for converting the servers set into
-// a slice of type Server
-func expandVpcServersSet(servers *schema.Set) []couchbasecapella.Server {
-	result := make([]couchbasecapella.Server, servers.Len())
+// a slice of type vpcServer
+func expandVpcServersSet(servers *schema.Set) []vpcServer {
+	result := make([]vpcServer, servers.Len())
 
 	for i, value := range servers.List() {
 		v := value.(map[string]interface{})
@@ -291,6 +567,19 @@ func expandVpcServiceList(services []interface{}) (res []couchbasecapella.Couchb
 	return res
 }
 
+// checkVpcServersProvider is responsible for checking that the servers of a vpc
+// cluster are all configured for the provider of its cloud.
+func checkVpcServersProvider(servers *schema.Set, providerName string) error {
+	providers := getVpcServersProvider(servers)
+	if len(providers) > 1 {
+		return fmt.Errorf(VpcClusterServerDoesNotMatchProvider)
+	}
+	if len(providers) == 1 && !Has(providers, providerName) {
+		return fmt.Errorf(VpcClusterServerDoesNotMatchProvider)
+	}
+	return nil
+}
+
 func getVpcServersProvider(servers *schema.Set) []string {
 	providers := make([]string, 0)
 
@@ -307,19 +596,24 @@ func getVpcServersProvider(servers *schema.Set) []string {
 					providers = append(providers, "azure")
 				}
 			}
+			if k == "gcp" && len(v.(*schema.Set).List()) > 0 {
+				if !Has(providers, "gcp") {
+					providers = append(providers, "gcp")
+				}
+			}
 		}
 	}
 	return providers
 }
 
-func createVpcServer(v map[string]interface{}) couchbasecapella.Server {
-	var server couchbasecapella.Server
+func createVpcServer(v map[string]interface{}) vpcServer {
+	var server vpcServer
 	for _, awss := range v["aws"].(*schema.Set).List() {
 		aws, ok := awss.(map[string]interface{})
 		if ok {
-			server = couchbasecapella.Server{
+			server = vpcServer{
 				Size:     int32(v["size"].(int)),
-				Services: expandVpcServiceList(v["services"].([]interface{})),
+				Services: expandVpcServiceList(v["services"].(*schema.Set).List()),
 				Aws: &couchbasecapella.ServerAws{
 					InstanceSize: couchbasecapella.AwsInstances(aws["instance_size"].(string)),
 					EbsSizeGib:   int32(aws["ebs_size_gib"].(int)),
@@ -330,9 +624,9 @@ func createVpcServer(v map[string]interface{}) couchbasecapella.Server {
 	for _, azures := range v["azure"].(*schema.Set).List() {
 		azure, ok := azures.(map[string]interface{})
 		if ok {
-			server = couchbasecapella.Server{
+			server = vpcServer{
 				Size:     int32(v["size"].(int)),
-				Services: expandVpcServiceList(v["services"].([]interface{})),
+				Services: expandVpcServiceList(v["services"].(*schema.Set).List()),
 				Azure: &couchbasecapella.ServerAzure{
 					InstanceSize: couchbasecapella.AzureInstances(azure["instance_size"].(string)),
 					VolumeType:   couchbasecapella.AzureVolumeTypes(azure["volume_type"].(string)),
@@ -340,6 +634,67 @@ func createVpcServer(v map[string]interface{}) couchbasecapella.Server {
 			}
 		}
 	}
+	for _, gcps := range v["gcp"].(*schema.Set).List() {
+		gcp, ok := gcps.(map[string]interface{})
+		if ok {
+			server = vpcServer{
+				Size:     int32(v["size"].(int)),
+				Services: expandVpcServiceList(v["services"].(*schema.Set).List()),
+				Gcp: &vpcServerGcp{
+					InstanceSize: gcp["instance_size"].(string),
+					DiskSizeGib:  int32(gcp["disk_size_gib"].(int)),
+				},
+			}
+		}
+	}
 
 	return server
 }
+
+// flattenVpcServers is responsible for converting the server groups of a vpc cluster
+// into the servers set.
+func flattenVpcServers(servers []vpcServer) []interface{} {
+	servs := make([]interface{}, len(servers))
+	for i, server := range servers {
+		services := make([]interface{}, len(server.Services))
+		for j, service := range server.Services {
+			services[j] = string(service)
+		}
+		serv := map[string]interface{}{
+			"size":     int(server.Size),
+			"services": services,
+		}
+		if server.Aws != nil {
+			serv["aws"] = []interface{}{map[string]interface{}{
+				"instance_size": string(server.Aws.InstanceSize),
+				"ebs_size_gib":  int(server.Aws.EbsSizeGib),
+			}}
+		}
+		if server.Azure != nil {
+			serv["azure"] = []interface{}{map[string]interface{}{
+				"instance_size": string(server.Azure.InstanceSize),
+				"volume_type":   string(server.Azure.VolumeType),
+			}}
+		}
+		if server.Gcp != nil {
+			serv["gcp"] = []interface{}{map[string]interface{}{
+				"instance_size": server.Gcp.InstanceSize,
+				"disk_size_gib": int(server.Gcp.DiskSizeGib),
+			}}
+		}
+		servs[i] = serv
+	}
+	return servs
+}
+
+// vpcClusterServerVersion is responsible for returning the Couchbase Server version
+// of a vpc cluster, falling back to the name of its version.
+func vpcClusterServerVersion(version *couchbasecapella.ClusterVersion) string {
+	if version == nil {
+		return ""
+	}
+	if v := version.Components["cbServerVersion"]; v != "" {
+		return v
+	}
+	return version.Name
+}
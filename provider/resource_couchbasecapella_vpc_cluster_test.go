@@ -11,19 +11,24 @@ package provider
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
+	"strings"
 	"testing"
 
 	couchbasecapella "github.com/couchbasecloud/couchbase-capella-api-go-client"
+	"github.com/couchbasecloud/terraform-provider-couchbasecapella/provider/internal/capellamock"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 // Test to see if a vpc cluster can be created, exists and is deleted successfully in AWS
 func TestAccCouchbaseCapellaVpcCluster_AWS(t *testing.T) {
 	var (
-		cluster couchbasecapella.Cluster
+		cluster   couchbasecapella.Cluster
+		clusterId string
 	)
 
 	resourceName := "couchbasecapella_vpc_cluster.test"
@@ -40,6 +45,20 @@ func TestAccCouchbaseCapellaVpcCluster_AWS(t *testing.T) {
 				Config: testAccCouchbaseCapellaVpcClusterConfig_AWS(clusterName, cloudId, projectId),
 				Check: resource.ComposeTestCheckFunc(
 					testAccCheckCouchbaseCapellaVpcClusterExists(resourceName, &cluster),
+					resource.TestCheckResourceAttr(resourceName, "status", "ready"),
+					resource.TestCheckResourceAttr(resourceName, "servers.#", "1"),
+					resource.TestCheckResourceAttrSet(resourceName, "server_version"),
+					resource.TestCheckResourceAttrSet(resourceName, "endpoints_srv"),
+					testAccCheckResourceIdUnchanged(resourceName, &clusterId),
+				),
+			},
+			{
+				Config: testAccCouchbaseCapellaVpcClusterConfig_AWSWithScaledServers(clusterName, cloudId, projectId),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCouchbaseCapellaVpcClusterExists(resourceName, &cluster),
+					testAccCheckResourceIdUnchanged(resourceName, &clusterId),
+					resource.TestCheckResourceAttr(resourceName, "status", "ready"),
+					resource.TestCheckResourceAttr(resourceName, "servers.#", "1"),
 				),
 			},
 		},
@@ -66,27 +85,492 @@ func TestAccCouchbaseCapellaVpcCluster_Azure(t *testing.T) {
 				Config: testAccCouchbaseCapellaVpcClusterConfig_Azure(clusterName, cloudId, projectId),
 				Check: resource.ComposeTestCheckFunc(
 					testAccCheckCouchbaseCapellaVpcClusterExists(resourceName, &cluster),
+					resource.TestCheckResourceAttr(resourceName, "status", "ready"),
+					resource.TestCheckResourceAttr(resourceName, "servers.#", "1"),
+					resource.TestCheckResourceAttrSet(resourceName, "server_version"),
+					resource.TestCheckResourceAttrSet(resourceName, "endpoints_srv"),
 				),
 			},
 		},
 	})
 }
 
-// Test to see if vpc cluster has been destroyed after Terraform Destory has been executed
-func testAccCheckCouchbaseCapellaVpcClusterDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*couchbasecapella.APIClient)
-	auth := context.WithValue(
-		context.Background(),
-		couchbasecapella.ContextAPIKeys,
-		map[string]couchbasecapella.APIKey{
-			"accessKey": {
-				Key: os.Getenv("CBC_ACCESS_KEY"),
+// Test to see if a vpc cluster can be created, exists and is deleted successfully in GCP
+func TestAccCouchbaseCapellaVpcCluster_GCP(t *testing.T) {
+	var (
+		cluster couchbasecapella.Cluster
+	)
+
+	resourceName := "couchbasecapella_vpc_cluster.test"
+	clusterName := fmt.Sprintf("testacc-vpc-%s", acctest.RandString(5))
+	cloudId := os.Getenv("CBC_GCP_CLOUD_ID")
+	projectId := os.Getenv("CBC_PROJECT_ID")
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckCouchbaseCapellaVpcClusterDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCouchbaseCapellaVpcClusterConfig_GCP(clusterName, cloudId, projectId),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckCouchbaseCapellaVpcClusterExists(resourceName, &cluster),
+					resource.TestCheckResourceAttr(resourceName, "status", "ready"),
+					resource.TestCheckResourceAttr(resourceName, "servers.#", "1"),
+				),
 			},
-			"secretKey": {
-				Key: os.Getenv("CBC_SECRET_KEY"),
+		},
+	})
+}
+
+// Test to see if the vpc cluster CRUD functions make the expected calls to the Capella API
+func TestVpcClusterCRUD(t *testing.T) {
+	raw := testVpcClusterRaw
+	addCloud := func(f *fakeCapellaClient, provider couchbasecapella.Provider) {
+		f.clouds["cloud-1"] = couchbasecapella.Cloud{Id: "cloud-1", Provider: provider}
+	}
+	addCluster := func(f *fakeCapellaClient, status couchbasecapella.ClusterStatus) string {
+		f.vpcClusters["vpc-cluster-1"] = vpcCluster{Cluster: couchbasecapella.Cluster{Id: "vpc-cluster-1", Name: "cluster", CloudId: "cloud-1", Status: status}}
+		return "vpc-cluster-1"
+	}
+
+	runCRUDTestCases(t, resourceCouchbaseCapellaVpcCluster(), []crudTestCase{
+		{
+			name:  "create",
+			raw:   raw("cloud-1"),
+			crud:  resourceCouchbaseCapellaVpcClusterCreate,
+			setup: func(f *fakeCapellaClient) string { addCloud(f, couchbasecapella.PROVIDER_AWS); return "" },
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if cluster, ok := f.vpcClusters[d.Id()]; !ok || cluster.Name != "cluster" {
+					t.Fatalf("expected cluster %s to be created, got %v", d.Id(), f.vpcClusters)
+				}
+				want := map[string]string{
+					"status":            "ready",
+					"server_version":    "7.1.1",
+					"endpoints_srv":     "cb.vpc-cluster-1.cloud.couchbase.com",
+					"endpoints_url.0":   "https://cb.vpc-cluster-1.cloud.couchbase.com",
+					"connection_string": "couchbases://cb.vpc-cluster-1.cloud.couchbase.com",
+				}
+				for key, value := range want {
+					if got := fmt.Sprint(d.Get(key)); got != value {
+						t.Fatalf("expected %s to be %q, got %q", key, value, got)
+					}
+				}
 			},
 		},
-	)
+		{
+			name: "read",
+			raw:  raw("cloud-1"),
+			crud: resourceCouchbaseCapellaVpcClusterRead,
+			setup: func(f *fakeCapellaClient) string {
+				addCluster(f, couchbasecapella.CLUSTERSTATUS_READY)
+				cluster := f.vpcClusters["vpc-cluster-1"]
+				cluster.Name = "renamed"
+				cluster.ProjectId = "project-2"
+				cluster.Servers = []vpcServer{{
+					Size:     5,
+					Services: []couchbasecapella.CouchbaseServices{"data", "index"},
+					Azure:    &couchbasecapella.ServerAzure{InstanceSize: "Standard_F4s_v2", VolumeType: "P6"},
+				}}
+				f.vpcClusters["vpc-cluster-1"] = cluster
+				return "vpc-cluster-1"
+			},
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if name, projectId := d.Get("name"), d.Get("project_id"); name != "renamed" || projectId != "project-2" {
+					t.Fatalf("expected the name and project_id to be read, got %v and %v", name, projectId)
+				}
+				if size := testNestedSetValue(d, "servers.size"); size != 5 {
+					t.Fatalf("expected the servers to be read, got a size of %v", size)
+				}
+				if services := testNestedSetValue(d, "servers.services").(*schema.Set); services.Len() != 2 {
+					t.Fatalf("expected the services to be read, got %v", services.List())
+				}
+				if aws := testNestedSetValue(d, "servers.aws").(*schema.Set); aws.Len() != 0 {
+					t.Fatalf("expected the aws configuration to be removed, got %v", aws.List())
+				}
+				if volume := testNestedSetValue(d, "servers.azure.volume_type"); volume != "P6" {
+					t.Fatalf("expected the azure configuration to be read, got a volume type of %v", volume)
+				}
+				if status := d.Get("status"); status != "ready" {
+					t.Fatalf("expected the status to be read, got %v", status)
+				}
+			},
+		},
+		{
+			name:  "read without servers",
+			raw:   raw("cloud-1"),
+			crud:  resourceCouchbaseCapellaVpcClusterRead,
+			setup: func(f *fakeCapellaClient) string { return addCluster(f, couchbasecapella.CLUSTERSTATUS_READY) },
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if instance := testNestedSetValue(d, "servers.aws.instance_size"); instance != "m5.xlarge" {
+					t.Fatalf("expected the servers in the state to be kept, got an instance size of %v", instance)
+				}
+			},
+		},
+		{
+			name:  "create in gcp",
+			raw:   testVpcClusterRawGcp("cloud-1"),
+			crud:  resourceCouchbaseCapellaVpcClusterCreate,
+			setup: func(f *fakeCapellaClient) string { addCloud(f, couchbasecapella.PROVIDER_GCP); return "" },
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				servers := f.vpcClusters[d.Id()].Servers
+				if len(servers) != 1 || servers[0].Gcp == nil || servers[0].Gcp.InstanceSize != "n2-standard-4" || servers[0].Gcp.DiskSizeGib != 50 {
+					t.Fatalf("expected the cluster to be created with gcp servers, got %+v", servers)
+				}
+				if disk := testNestedSetValue(d, "servers.gcp.disk_size_gib"); disk != 50 {
+					t.Fatalf("expected the gcp servers to be read, got a disk size of %v", disk)
+				}
+			},
+		},
+		{
+			name:    "create with gcp servers in another provider",
+			raw:     testVpcClusterRawGcp("cloud-1"),
+			crud:    resourceCouchbaseCapellaVpcClusterCreate,
+			setup:   func(f *fakeCapellaClient) string { addCloud(f, couchbasecapella.PROVIDER_AWS); return "" },
+			wantErr: VpcClusterServerDoesNotMatchProvider,
+		},
+		{
+			name:    "create in a missing cloud",
+			raw:     raw("cloud-2"),
+			crud:    resourceCouchbaseCapellaVpcClusterCreate,
+			wantErr: "404: the cloud doesn't exist",
+		},
+		{
+			name:    "create with servers of another provider",
+			raw:     raw("cloud-1"),
+			crud:    resourceCouchbaseCapellaVpcClusterCreate,
+			setup:   func(f *fakeCapellaClient) string { addCloud(f, couchbasecapella.PROVIDER_AZURE); return "" },
+			wantErr: VpcClusterServerDoesNotMatchProvider,
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if f.called("CreateVpcCluster") {
+					t.Fatal("expected the cluster not to be created")
+				}
+			},
+		},
+		{
+			name:  "read deleted elsewhere",
+			raw:   raw("cloud-1"),
+			crud:  resourceCouchbaseCapellaVpcClusterRead,
+			setup: func(f *fakeCapellaClient) string { return "vpc-cluster-1" },
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if d.Id() != "" {
+					t.Fatalf("expected the cluster to be removed from the state, got %q", d.Id())
+				}
+			},
+		},
+		{
+			name:  "delete",
+			raw:   raw("cloud-1"),
+			crud:  resourceCouchbaseCapellaVpcClusterDelete,
+			setup: func(f *fakeCapellaClient) string { return addCluster(f, couchbasecapella.CLUSTERSTATUS_READY) },
+			check: func(t *testing.T, f *fakeCapellaClient, d *schema.ResourceData) {
+				if len(f.vpcClusters) != 0 {
+					t.Fatalf("expected the cluster to be deleted, got %v", f.vpcClusters)
+				}
+			},
+		},
+		{
+			name:    "delete while deploying",
+			raw:     raw("cloud-1"),
+			crud:    resourceCouchbaseCapellaVpcClusterDelete,
+			setup:   func(f *fakeCapellaClient) string { return addCluster(f, couchbasecapella.CLUSTERSTATUS_DEPLOYING) },
+			wantErr: "VPC Cluster is not ready to be deleted",
+		},
+	})
+}
+
+// Test to see if updating the servers of a vpc cluster scales them in place and waits for the cluster to be ready
+func TestVpcClusterUpdate(t *testing.T) {
+	shortenPollIntervals(t)
+	scaled := testVpcClusterRaw("cloud-1")
+	server := scaled["servers"].([]interface{})[0].(map[string]interface{})
+	server["size"] = 5
+	server["aws"] = []interface{}{map[string]interface{}{"instance_size": "m5.2xlarge", "ebs_size_gib": 100}}
+	azure := testVpcClusterRaw("cloud-1")
+	azure["servers"].([]interface{})[0].(map[string]interface{})["aws"] = []interface{}{}
+	azure["servers"].([]interface{})[0].(map[string]interface{})["azure"] = []interface{}{map[string]interface{}{"instance_size": "Standard_F4s_v2", "volume_type": "P6"}}
+
+	testCases := []struct {
+		name      string
+		raw       map[string]interface{}
+		wantErr   string
+		wantCalls []string
+	}{
+		{name: "servers", raw: scaled, wantCalls: []string{"GetCloud", "UpdateVpcClusterServers", "GetVpcClusterStatus", "GetVpcCluster"}},
+		{name: "servers of another provider", raw: azure, wantErr: VpcClusterServerDoesNotMatchProvider, wantCalls: []string{"GetCloud"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeCapellaClient()
+			f.clouds["cloud-1"] = couchbasecapella.Cloud{Id: "cloud-1", Provider: couchbasecapella.PROVIDER_AWS}
+			id := "vpc-cluster-1"
+			f.vpcClusters[id] = vpcCluster{Cluster: couchbasecapella.Cluster{Id: id, Name: "cluster", CloudId: "cloud-1", Status: couchbasecapella.CLUSTERSTATUS_READY}}
+
+			r := resourceCouchbaseCapellaVpcCluster()
+			d := testResourceDataUpdate(t, r, id, testVpcClusterRaw("cloud-1"), tc.raw, f)
+			diags := resourceCouchbaseCapellaVpcClusterUpdate(context.Background(), d, f)
+			if tc.wantErr == "" && diags.HasError() {
+				t.Fatalf("err: %v", diags)
+			}
+			if tc.wantErr != "" && (!diags.HasError() || diags[0].Summary != tc.wantErr) {
+				t.Fatalf("expected the error %q, got %v", tc.wantErr, diags)
+			}
+			if fmt.Sprint(f.calls) != fmt.Sprint(tc.wantCalls) {
+				t.Fatalf("expected the calls %v, got %v", tc.wantCalls, f.calls)
+			}
+			if tc.wantErr == "" {
+				servers := f.vpcClusters[id].Servers
+				if len(servers) != 1 || servers[0].Size != 5 || servers[0].Aws.InstanceSize != "m5.2xlarge" || servers[0].Aws.EbsSizeGib != 100 {
+					t.Fatalf("expected the servers to be scaled, got %+v", servers)
+				}
+				if size := testNestedSetValue(d, "servers.size"); size != 5 {
+					t.Fatalf("expected the scaled servers to be read, got a size of %v", size)
+				}
+			}
+		})
+	}
+}
+
+// Test to see if scaling a vpc cluster waits through the statuses Capella reports before deploying it
+func TestVpcClusterUpdate_intermediateStatuses(t *testing.T) {
+	shortenPollIntervals(t)
+	scaled := testVpcClusterRaw("cloud-1")
+	scaled["servers"].([]interface{})[0].(map[string]interface{})["size"] = 5
+
+	f := newFakeCapellaClient()
+	f.clouds["cloud-1"] = couchbasecapella.Cloud{Id: "cloud-1", Provider: couchbasecapella.PROVIDER_AWS}
+	id := "vpc-cluster-1"
+	f.vpcClusters[id] = vpcCluster{Cluster: couchbasecapella.Cluster{Id: id, Name: "cluster", CloudId: "cloud-1", Status: couchbasecapella.CLUSTERSTATUS_READY}}
+	f.vpcStatuses[id] = []couchbasecapella.ClusterStatus{
+		couchbasecapella.CLUSTERSTATUS_NEEDS_DEPLOY,
+		couchbasecapella.CLUSTERSTATUS_JOB_SCHEDULED,
+		couchbasecapella.CLUSTERSTATUS_DEPLOYING,
+		couchbasecapella.CLUSTERSTATUS_DEPLOY_SUCCEEDED,
+	}
+
+	r := resourceCouchbaseCapellaVpcCluster()
+	d := testResourceDataUpdate(t, r, id, testVpcClusterRaw("cloud-1"), scaled, f)
+	if diags := resourceCouchbaseCapellaVpcClusterUpdate(context.Background(), d, f); diags.HasError() {
+		t.Fatalf("err: %v", diags)
+	}
+	if statuses := f.vpcStatuses[id]; len(statuses) != 0 {
+		t.Fatalf("expected every status to be read, %v are left", statuses)
+	}
+}
+
+// Test to see if changes to the servers of a vpc cluster only replace it when a volume shrinks
+func TestVpcClusterDiff_servers(t *testing.T) {
+	withServer := func(change func(server map[string]interface{})) map[string]interface{} {
+		raw := testVpcClusterRaw("cloud-1")
+		change(raw["servers"].([]interface{})[0].(map[string]interface{}))
+		return raw
+	}
+	withAws := func(instance string, size int) map[string]interface{} {
+		return withServer(func(server map[string]interface{}) {
+			server["aws"] = []interface{}{map[string]interface{}{"instance_size": instance, "ebs_size_gib": size}}
+		})
+	}
+	withAzure := func(volume string) map[string]interface{} {
+		return withServer(func(server map[string]interface{}) {
+			server["aws"] = []interface{}{}
+			server["azure"] = []interface{}{map[string]interface{}{"instance_size": "Standard_F4s_v2", "volume_type": volume}}
+		})
+	}
+
+	withGcp := func(size int) map[string]interface{} {
+		raw := testVpcClusterRawGcp("cloud-1")
+		raw["servers"].([]interface{})[0].(map[string]interface{})["gcp"] = []interface{}{map[string]interface{}{"instance_size": "n2-standard-4", "disk_size_gib": size}}
+		return raw
+	}
+	renamed := testVpcClusterRaw("cloud-1")
+	renamed["name"] = "renamed"
+
+	testCases := []struct {
+		name        string
+		old, new    map[string]interface{}
+		wantReplace bool
+	}{
+		{name: "size", old: testVpcClusterRaw("cloud-1"), new: withServer(func(server map[string]interface{}) { server["size"] = 5 })},
+		{name: "instance size", old: testVpcClusterRaw("cloud-1"), new: withAws("m5.2xlarge", 50)},
+		{name: "ebs size increase", old: testVpcClusterRaw("cloud-1"), new: withAws("m5.xlarge", 100)},
+		{name: "ebs size decrease", old: withAws("m5.xlarge", 100), new: testVpcClusterRaw("cloud-1"), wantReplace: true},
+		{name: "azure volume upgrade", old: withAzure("P6"), new: withAzure("P10")},
+		{name: "azure volume downgrade", old: withAzure("P10"), new: withAzure("P6"), wantReplace: true},
+		{name: "gcp disk size increase", old: testVpcClusterRawGcp("cloud-1"), new: withGcp(100)},
+		{name: "gcp disk size decrease", old: withGcp(100), new: testVpcClusterRawGcp("cloud-1"), wantReplace: true},
+		{name: "name", old: testVpcClusterRaw("cloud-1"), new: renamed, wantReplace: true},
+	}
+
+	r := resourceCouchbaseCapellaVpcCluster()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, diff, err := testResourceDiff(t, r, "vpc-cluster-1", tc.old, tc.new, newFakeCapellaClient())
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if diff == nil || len(diff.Attributes) == 0 {
+				t.Fatal("expected the cluster to change")
+			}
+			if diff.RequiresNew() != tc.wantReplace {
+				t.Fatalf("expected replacement to be %t, got %t", tc.wantReplace, diff.RequiresNew())
+			}
+		})
+	}
+}
+
+// Test to see if the status of a vpc cluster is only reported as gone once the cluster can't be found
+func TestVpcClusterStatusRefreshFunc(t *testing.T) {
+	server, client := newTestMockClient(t)
+	projectId := server.AddProject("project")
+	clusterId := server.AddVpcCluster(projectId, server.AddCloud("aws", "us-east-1", "10.0.0.0/16"), "cluster")
+	ctx := context.Background()
+
+	testCases := []struct {
+		name       string
+		clusterId  string
+		fault      *capellamock.Fault
+		wantResult bool
+		wantState  string
+	}{
+		{name: "ready", clusterId: clusterId, wantResult: true, wantState: "ready"},
+		{name: "empty status", clusterId: clusterId, fault: &capellamock.Fault{Path: "v2/clusters/*/status", Count: 1, EmptyBody: true}},
+		{name: "gone", clusterId: "00000000-0000-0000-0000-000000000000", wantResult: true, wantState: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.fault != nil {
+				server.Inject(*tc.fault)
+			}
+			result, state, err := vpcClusterStatusRefreshFunc(ctx, client, tc.clusterId)()
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if (result != nil) != tc.wantResult || state != tc.wantState {
+				t.Fatalf("expected a result %t with state %q, got %v with state %q", tc.wantResult, tc.wantState, result, state)
+			}
+		})
+	}
+
+	server.Inject(capellamock.Fault{Path: "v2/clusters/*/status", Count: 1, Status: http.StatusForbidden})
+	if _, _, err := vpcClusterStatusRefreshFunc(ctx, client, clusterId)(); err == nil {
+		t.Fatal("expected an error for a 403 response")
+	}
+}
+
+// Test to see if the topology of the server groups of both cluster resources is validated when planning
+func TestClusterDiff_topology(t *testing.T) {
+	resources := map[string]struct {
+		resource  *schema.Resource
+		raw       func() map[string]interface{}
+		name      string
+		maxGroups int
+	}{
+		"hosted": {resourceCouchbaseCapellaHostedCluster(), func() map[string]interface{} { return testHostedClusterRaw("project-1") }, "couchbasecapella_hosted_cluster", maxHostedServerGroups},
+		"vpc":    {resourceCouchbaseCapellaVpcCluster(), func() map[string]interface{} { return testVpcClusterRaw("cloud-1") }, "couchbasecapella_vpc_cluster", maxVpcServerGroups},
+	}
+
+	type topologyTestCase struct {
+		name     string
+		services [][]interface{}
+		wantErrs []string
+	}
+	testCases := []topologyTestCase{
+		{name: "data only", services: [][]interface{}{{"data"}}},
+		{name: "separate groups", services: [][]interface{}{{"data", "index"}, {"query", "search"}}},
+		{
+			name:     "no data service",
+			services: [][]interface{}{{"index", "query"}},
+			wantErrs: []string{ClusterMissingDataService},
+		},
+		{
+			name:     "service in two groups",
+			services: [][]interface{}{{"data", "index"}, {"index", "query"}},
+			wantErrs: []string{"the index service is already in the data,index server group"},
+		},
+	}
+
+	for name, r := range resources {
+		// The most groups the resource allows, and one more, each with a service of its own.
+		services := []interface{}{"data", "index", "query", "search", "eventing", "analytics"}
+		var groups [][]interface{}
+		for _, service := range services[:r.maxGroups+1] {
+			groups = append(groups, []interface{}{service})
+		}
+		testCases := append(testCases,
+			topologyTestCase{name: "most groups", services: groups[:r.maxGroups]},
+			topologyTestCase{
+				name:     "too many groups",
+				services: groups,
+				wantErrs: []string{fmt.Sprintf(ClusterInvalidServerGroupCount, r.name, r.maxGroups, r.maxGroups+1)},
+			},
+		)
+		for _, tc := range testCases {
+			t.Run(name+" "+tc.name, func(t *testing.T) {
+				raw := r.raw()
+				template := raw["servers"].([]interface{})[0].(map[string]interface{})
+				var servers []interface{}
+				for _, services := range tc.services {
+					server := make(map[string]interface{}, len(template))
+					for k, v := range template {
+						server[k] = v
+					}
+					server["services"] = services
+					servers = append(servers, server)
+				}
+				raw["servers"] = servers
+
+				_, _, err := testResourceDiff(t, r.resource, "", nil, raw, newFakeCapellaClient())
+				if len(tc.wantErrs) == 0 {
+					if err != nil {
+						t.Fatalf("err: %s", err)
+					}
+					return
+				}
+				for _, want := range tc.wantErrs {
+					if err == nil || !strings.Contains(err.Error(), want) {
+						t.Fatalf("expected the error %q, got %v", want, err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// testVpcClusterRaw is the raw configuration of a vpc cluster used by the unit tests
+func testVpcClusterRaw(cloudId string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":       "cluster",
+		"cloud_id":   cloudId,
+		"project_id": "project-1",
+		"servers": []interface{}{map[string]interface{}{
+			"size":     3,
+			"services": []interface{}{"data"},
+			"aws": []interface{}{map[string]interface{}{
+				"instance_size": "m5.xlarge",
+				"ebs_size_gib":  50,
+			}},
+		}},
+	}
+}
+
+// testVpcClusterRawGcp is the raw configuration of a vpc cluster in GCP used by the unit tests
+func testVpcClusterRawGcp(cloudId string) map[string]interface{} {
+	raw := testVpcClusterRaw(cloudId)
+	server := raw["servers"].([]interface{})[0].(map[string]interface{})
+	delete(server, "aws")
+	server["gcp"] = []interface{}{map[string]interface{}{
+		"instance_size": "n2-standard-4",
+		"disk_size_gib": 50,
+	}}
+	return raw
+}
+
+// Test to see if vpc cluster has been destroyed after Terraform Destory has been executed
+func testAccCheckCouchbaseCapellaVpcClusterDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*Client)
+	auth := client.getAuth(context.Background())
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "couchbasecapella_vpc_cluster" {
@@ -105,19 +589,8 @@ func testAccCheckCouchbaseCapellaVpcClusterDestroy(s *terraform.State) error {
 // Test to see if vpc cluster exists after Terraform Apply has been executed
 func testAccCheckCouchbaseCapellaVpcClusterExists(resourceName string, cluster *couchbasecapella.Cluster) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
-		client := testAccProvider.Meta().(*couchbasecapella.APIClient)
-		auth := context.WithValue(
-			context.Background(),
-			couchbasecapella.ContextAPIKeys,
-			map[string]couchbasecapella.APIKey{
-				"accessKey": {
-					Key: os.Getenv("CBC_ACCESS_KEY"),
-				},
-				"secretKey": {
-					Key: os.Getenv("CBC_SECRET_KEY"),
-				},
-			},
-		)
+		client := testAccProvider.Meta().(*Client)
+		auth := client.getAuth(context.Background())
 
 		rs, ok := s.RootModule().Resources[resourceName]
 		if !ok {
@@ -156,6 +629,25 @@ func testAccCouchbaseCapellaVpcClusterConfig_AWS(clusterName, cloudId, projectId
 	`, clusterName, cloudId, projectId)
 }
 
+// This is the Terraform Configuration that will be applied for the testing the scaling of a cluster deployed in AWS
+func testAccCouchbaseCapellaVpcClusterConfig_AWSWithScaledServers(clusterName, cloudId, projectId string) string {
+	return fmt.Sprintf(`
+		resource "couchbasecapella_vpc_cluster" "test" {
+			name       = "%s"
+			cloud_id   = "%s"
+			project_id = "%s"
+			servers {
+				size     = 4
+				services = ["data"]
+				aws {
+					instance_size = "m5.2xlarge"
+					ebs_size_gib  = 100
+				}
+			}
+		}
+	`, clusterName, cloudId, projectId)
+}
+
 // This is the Terraform Configuration that will be applied for the testing a cluster deployed in Azure
 func testAccCouchbaseCapellaVpcClusterConfig_Azure(clusterName, cloudId, projectId string) string {
 	return fmt.Sprintf(`
@@ -174,3 +666,22 @@ func testAccCouchbaseCapellaVpcClusterConfig_Azure(clusterName, cloudId, project
 		}
 	`, clusterName, cloudId, projectId)
 }
+
+// This is the Terraform Configuration that will be applied for the testing a cluster deployed in GCP
+func testAccCouchbaseCapellaVpcClusterConfig_GCP(clusterName, cloudId, projectId string) string {
+	return fmt.Sprintf(`
+		resource "couchbasecapella_vpc_cluster" "test" {
+			name       = "%s"
+			cloud_id   = "%s"
+			project_id = "%s"
+			servers {
+				size     = 3
+				services = ["data", "query", "index"]
+				gcp {
+					instance_size = "n2-standard-4"
+					disk_size_gib = 50
+				}
+			}
+		}
+	`, clusterName, cloudId, projectId)
+}
@@ -0,0 +1,171 @@
+// Couchbase, Inc. licenses this to you under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at https://www.apache.org/licenses/LICENSE-2.0.
+
+// Unless required by applicable law or agreed to in writing, software distributed under the License
+// is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and limitations under the License.
+
+package provider
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	defaultMaxRetries   = 4
+	defaultRetryMinWait = 1 * time.Second
+	defaultRetryMaxWait = 30 * time.Second
+)
+
+// retryTransport is an http.RoundTripper that retries idempotent requests to the
+// Capella API when they are throttled or fail with a transient error. Retries use
+// exponential backoff with jitter, unless the API asks for a delay with Retry-After.
+type retryTransport struct {
+	next       http.RoundTripper
+	maxRetries int
+	minWait    time.Duration
+	maxWait    time.Duration
+}
+
+func newRetryTransport(next http.RoundTripper, maxRetries int, minWait, maxWait time.Duration) *retryTransport {
+	if minWait <= 0 {
+		minWait = defaultRetryMinWait
+	}
+	if maxWait < minWait {
+		maxWait = defaultRetryMaxWait
+	}
+	return &retryTransport{
+		next:       next,
+		maxRetries: maxRetries,
+		minWait:    minWait,
+		maxWait:    maxWait,
+	}
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isIdempotent(req.Method) {
+		return t.next.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.Body != nil {
+			// The previous attempt consumed the body, so rewind it before retrying.
+			if req.GetBody == nil {
+				return nil, RetryBodyNotRewindable
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+
+		resp, err := t.next.RoundTrip(req)
+		if attempt >= t.maxRetries || ctx.Err() != nil || !shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		wait := t.backoff(attempt, resp)
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+			// Waiting would outlive the caller, hand back the last outcome instead.
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		tflog.SubsystemDebug(ctx, logSubsystem, "Retrying Capella API request", map[string]interface{}{
+			"method":  req.Method,
+			"path":    req.URL.Path,
+			"attempt": attempt + 1,
+			"wait_ms": wait.Milliseconds(),
+		})
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns how long to wait before the next attempt. A Retry-After header
+// sent by the API takes precedence over the computed exponential backoff, but is
+// still capped at maxWait so a large delay can't stall a plan.
+func (t *retryTransport) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			if wait > t.maxWait {
+				wait = t.maxWait
+			}
+			return wait
+		}
+	}
+
+	wait := t.minWait << uint(attempt)
+	if wait <= 0 || wait > t.maxWait {
+		wait = t.maxWait
+	}
+	// Equal jitter: keep half of the backoff and randomise the other half so
+	// concurrent resources don't retry in lockstep.
+	half := wait / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// shouldRetry reports whether a request failed in a way that is worth retrying.
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isIdempotent reports whether a request with the given method can safely be sent twice.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+// Couchbase, Inc. licenses this to you under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at https://www.apache.org/licenses/LICENSE-2.0.
+
+// Unless required by applicable law or agreed to in writing, software distributed under the License
+// is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and limitations under the License.
+
+package provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Test to see if idempotent requests are retried on throttling and transient errors
+// and that non-idempotent requests are sent only once
+func TestRetryTransport(t *testing.T) {
+	testCases := []struct {
+		name       string
+		method     string
+		statuses   []int
+		maxRetries int
+		wantStatus int
+		wantCalls  int32
+	}{
+		{"retries throttled GET", http.MethodGet, []int{429, 429, 200}, 4, 200, 3},
+		{"retries server errors on DELETE", http.MethodDelete, []int{500, 502, 503, 504, 202}, 4, 202, 5},
+		{"gives up after max retries", http.MethodGet, []int{503, 503, 503}, 2, 503, 3},
+		{"does not retry POST", http.MethodPost, []int{429, 201}, 4, 429, 1},
+		{"does not retry client errors", http.MethodPut, []int{422, 200}, 4, 422, 1},
+		{"disabled with zero retries", http.MethodGet, []int{429, 200}, 0, 429, 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				call := atomic.AddInt32(&calls, 1)
+				w.WriteHeader(tc.statuses[call-1])
+			}))
+			defer server.Close()
+
+			client := &http.Client{Transport: newRetryTransport(http.DefaultTransport, tc.maxRetries, time.Millisecond, 5*time.Millisecond)}
+			req, _ := http.NewRequest(tc.method, server.URL, nil)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tc.wantStatus {
+				t.Fatalf("expected status %d, got %d", tc.wantStatus, resp.StatusCode)
+			}
+			if calls != tc.wantCalls {
+				t.Fatalf("expected %d calls, got %d", tc.wantCalls, calls)
+			}
+		})
+	}
+}
+
+// Test to see if the request body is sent again on every retry
+func TestRetryTransport_replaysBody(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"memoryQuota":256}` {
+			t.Errorf("unexpected body %q", body)
+		}
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: newRetryTransport(http.DefaultTransport, 2, time.Millisecond, 5*time.Millisecond)}
+	req, _ := http.NewRequest(http.MethodPut, server.URL, strings.NewReader(`{"memoryQuota":256}`))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || calls != 2 {
+		t.Fatalf("expected a successful retry, got status %d after %d calls", resp.StatusCode, calls)
+	}
+}
+
+// Test to see if Retry-After is honored up to the maximum wait and waiting stops when the context is cancelled
+func TestRetryTransport_retryAfter(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	transport := newRetryTransport(http.DefaultTransport, 4, time.Millisecond, 2*time.Minute)
+	if wait := transport.backoff(0, &http.Response{Header: http.Header{"Retry-After": []string{"60"}}}); wait != time.Minute {
+		t.Fatalf("expected Retry-After to set the backoff, got %s", wait)
+	}
+	capped := newRetryTransport(http.DefaultTransport, 4, time.Millisecond, 5*time.Millisecond)
+	if wait := capped.backoff(0, &http.Response{Header: http.Header{"Retry-After": []string{"86400"}}}); wait != 5*time.Millisecond {
+		t.Fatalf("expected Retry-After to be capped at the maximum wait, got %s", wait)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	client := &http.Client{Transport: transport}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	resp.Body.Close()
+
+	if time.Since(start) > time.Second {
+		t.Fatalf("retry waited past the context deadline")
+	}
+	if resp.StatusCode != http.StatusTooManyRequests || calls != 1 {
+		t.Fatalf("expected the throttled response to be returned, got status %d after %d calls", resp.StatusCode, calls)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if wait, ok := parseRetryAfter("3"); !ok || wait != 3*time.Second {
+		t.Fatalf("unexpected wait %s", wait)
+	}
+	date := time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat)
+	if wait, ok := parseRetryAfter(date); !ok || wait <= 0 || wait > 10*time.Second {
+		t.Fatalf("unexpected wait %s", wait)
+	}
+	if _, ok := parseRetryAfter("soon"); ok {
+		t.Fatalf("expected an invalid Retry-After to be ignored")
+	}
+}
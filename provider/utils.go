@@ -10,16 +10,39 @@ package provider
 
 import (
 	"context"
-	"io"
+	"fmt"
 	"net/http"
-	"os"
 	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
 
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
 	couchbasecapella "github.com/couchbasecloud/couchbase-capella-api-go-client"
 )
 
+// The polling intervals used while waiting on the Capella API. They are variables
+// so the tests running against the mock Capella API can shorten them.
+var (
+	// hostedClusterPollDelay and vpcClusterPollDelay are how long to wait before
+	// checking the status of a cluster for the first time.
+	hostedClusterPollDelay = 2 * time.Minute
+	vpcClusterPollDelay    = 5 * time.Minute
+	// clusterPollMinTimeout is the minimum time between two checks of the status of a cluster.
+	clusterPollMinTimeout = 30 * time.Second
+	// vpcClusterDeletePollMinTimeout is the minimum time between two checks of the
+	// status of a vpc cluster being deleted.
+	vpcClusterDeletePollMinTimeout = 5 * time.Second
+	// listPollInterval is the time between two reads of the list of buckets or
+	// database users of a cluster while waiting for one to appear.
+	listPollInterval = 2 * time.Second
+)
+
 func Has(list []string, a string) bool {
 	for _, b := range list {
 		if b == a {
@@ -29,38 +52,266 @@ func Has(list []string, a string) bool {
 	return false
 }
 
-// TODO: Get Auth with both env variable and terraform ones
-func getAuth(ctx context.Context) context.Context {
-	auth := context.WithValue(
-		context.Background(),
-		couchbasecapella.ContextAPIKeys,
-		map[string]couchbasecapella.APIKey{
-			"accessKey": {
-				Key: os.Getenv("CBC_ACCESS_KEY"),
-			},
-			"secretKey": {
-				Key: os.Getenv("CBC_SECRET_KEY"),
-			},
-		},
-	)
-	return auth
-}
-
-func manageErrors(err error, r http.Response, functionality string) diag.Diagnostics {
-	if err != nil {
-		switch r.StatusCode {
-		case 403:
-			return diag.Errorf("You don't have the required access to apply this function " + functionality)
-		case 401:
-			return diag.Errorf("Please verify the validity of your Access key and Secret key")
-		case 422:
-			body, _ := io.ReadAll(r.Body)
-			return diag.Errorf("Failed to create resource; API response: \n%s", body)
-		default:
-			return diag.FromErr(err)
+// manageErrors is responsible for turning a failed Capella API call into diagnostics.
+// The error payload is decoded so the message, hint and request ID reported by Capella
+// are surfaced, and validation failures on a field are attached to the matching argument.
+func manageErrors(err error, r *http.Response, functionality string) diag.Diagnostics {
+	if err == nil {
+		return nil
+	}
+
+	apiErr := newAPIError(err, r)
+	if apiErr == nil {
+		return diag.Errorf("%s: %s", functionality, err)
+	}
+
+	summary := fmt.Sprintf("%s: %s", functionality, apiErr.Kind)
+	if len(apiErr.Fields) == 0 {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  summary,
+			Detail:   apiErr.detail(apiErr.Message),
+		}}
+	}
+
+	fields := make([]string, 0, len(apiErr.Fields))
+	for field := range apiErr.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	diags := make(diag.Diagnostics, 0, len(fields))
+	for _, field := range fields {
+		diags = append(diags, diag.Diagnostic{
+			Severity:      diag.Error,
+			Summary:       summary,
+			Detail:        apiErr.detail(fmt.Sprintf("%s: %s", field, apiErr.Fields[field])),
+			AttributePath: attributePath(field),
+		})
+	}
+	return diags
+}
+
+// detail is responsible for building the detail of a diagnostic from the given
+// message and the hint, error code and request ID reported by Capella.
+func (e *APIError) detail(message string) string {
+	var lines []string
+	if message != "" {
+		lines = append(lines, message)
+	}
+	if e.Hint != "" {
+		lines = append(lines, "Hint: "+e.Hint)
+	}
+	if e.Code != "" {
+		lines = append(lines, fmt.Sprintf("Error code: %s (HTTP %d)", e.Code, e.StatusCode))
+	} else {
+		lines = append(lines, fmt.Sprintf("HTTP status: %d", e.StatusCode))
+	}
+	if e.RequestID != "" {
+		lines = append(lines, "Request ID: "+e.RequestID)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// emptyResponse is responsible for reporting a Capella API call that succeeded
+// without returning the body it should have.
+func emptyResponse(functionality string) diag.Diagnostics {
+	return diag.Errorf("%s: %s", functionality, ErrEmptyResponse)
+}
+
+// apiFieldAliases maps API field names onto resource arguments named differently.
+var apiFieldAliases = map[string]string{
+	"all_buckets_access": "all_bucket_access",
+	"access":             "buckets",
+}
+
+// attributePath is responsible for mapping an API field such as "servers[0].storage.IOPS"
+// onto the path of the top level resource argument it was configured with.
+func attributePath(field string) cty.Path {
+	name := field
+	if i := strings.IndexAny(name, ".["); i >= 0 {
+		name = name[:i]
+	}
+	name = toSnakeCase(name)
+	if alias, ok := apiFieldAliases[name]; ok {
+		name = alias
+	}
+	return cty.GetAttrPath(name)
+}
+
+// toSnakeCase converts a camelCase API field name, e.g. singleAZ, to snake_case.
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// customizeDiffProjectID is responsible for defaulting the project_id of a cluster
+// to the project_id set on the provider when the resource doesn't set its own.
+func customizeDiffProjectID(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if _, ok := d.GetOk("project_id"); ok {
+		return nil
+	}
+	// A project_id that is only known after apply is set, just not yet.
+	if config := d.GetRawConfig(); !config.IsNull() && !config.GetAttr("project_id").IsKnown() {
+		return nil
+	}
+	client, ok := meta.(capellaClient)
+	if !ok || client.DefaultProjectID() == "" {
+		return fmt.Errorf(ClusterMissingProjectID)
+	}
+	return d.SetNew("project_id", client.DefaultProjectID())
+}
+
+// clusterKind is the deployment model of a cluster, which decides the endpoints
+// of the Capella API its buckets and database users are managed through.
+type clusterKind int
+
+const (
+	vpcClusterKind clusterKind = iota
+	hostedClusterKind
+)
+
+// getClusterKind is responsible for finding out whether a cluster is deployed in a
+// VPC or hosted by Capella. Only a cluster the v2 endpoints report as not found is
+// looked up through the v3 endpoints, which are the only ones to know hosted clusters,
+// so any other failure is reported as it is rather than taken for a hosted cluster.
+func getClusterKind(ctx context.Context, client capellaClient, clusterId string) (clusterKind, diag.Diagnostics) {
+	_, r, err := client.GetVpcCluster(ctx, clusterId)
+	if err == nil {
+		return vpcClusterKind, nil
+	}
+	if r == nil || r.StatusCode != http.StatusNotFound {
+		return 0, manageErrors(err, r, ClusterProblemAccessing)
+	}
+	if _, r, err := client.GetHostedCluster(ctx, clusterId); err != nil {
+		return 0, manageErrors(err, r, ClusterProblemAccessing)
+	}
+	return hostedClusterKind, nil
+}
+
+// customizeDiffServerTopology is responsible for building the validation of the topology
+// of the server groups of a cluster resource when planning, rather than having Capella
+// reject it after a long create: the cluster must have the data service, each service
+// can only be in one server group and the resource can have at most maxGroups server
+// groups. Server groups whose services are only known after apply are left out.
+func customizeDiffServerTopology(resource string, maxGroups int) schema.CustomizeDiffFunc {
+	return func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+		return validateServerTopology(d, resource, maxGroups)
+	}
+}
+
+// validateServerTopology is responsible for validating the topology of the server groups
+// in the configuration of a cluster resource.
+func validateServerTopology(d *schema.ResourceDiff, resource string, maxGroups int) error {
+	config := d.GetRawConfig()
+	if config.IsNull() || !config.IsKnown() {
+		return nil
+	}
+	servers := config.GetAttr("servers")
+	if servers.IsNull() || !servers.IsKnown() {
+		return nil
+	}
+
+	var errs *multierror.Error
+	groups := configElements(servers)
+	if len(groups) > maxGroups {
+		errs = multierror.Append(errs, fmt.Errorf(ClusterInvalidServerGroupCount, resource, maxGroups, len(groups)))
+	}
+
+	hasData, allKnown := false, true
+	serviceGroups := make(map[string]string)
+	for _, server := range groups {
+		services := server.GetAttr("services")
+		if !services.IsWhollyKnown() {
+			allKnown = false
+			continue
+		}
+		group := configServerGroup(server)
+		for _, service := range configElements(services) {
+			name := configString(service)
+			if serviceGroups[name] != "" {
+				errs = multierror.Append(errs, fmt.Errorf(ClusterDuplicateService, group, name, serviceGroups[name]))
+			} else {
+				serviceGroups[name] = group
+			}
+			hasData = hasData || name == string(couchbasecapella.V3COUCHBASESERVICES_DATA)
+		}
+	}
+	if allKnown && len(groups) > 0 && !hasData {
+		errs = multierror.Append(errs, fmt.Errorf(ClusterMissingDataService))
+	}
+	return errs.ErrorOrNil()
+}
+
+// setElementAddress is responsible for building the address of an attribute of an
+// element of a set, which the set addresses by the element's nonnegative hash code.
+// Forcing a new resource on a set itself only replaces the resource when the number
+// of elements changes, so replacement is forced on such an address instead.
+func setElementAddress(key string, set *schema.Set, element interface{}, attribute string) string {
+	code := set.F(element)
+	if code < 0 {
+		code = -code
+	}
+	return fmt.Sprintf("%s.%d.%s", key, code, attribute)
+}
+
+// configElements is responsible for returning the elements of a set or list in
+// the configuration of a resource, or none when it isn't set or known yet.
+func configElements(value cty.Value) []cty.Value {
+	if value.IsNull() || !value.IsKnown() {
+		return nil
+	}
+	return value.AsValueSlice()
+}
+
+// configString is responsible for returning a string from the configuration of a
+// resource, or an empty string when it isn't set or known yet.
+func configString(value cty.Value) string {
+	if value.IsNull() || !value.IsKnown() {
+		return ""
+	}
+	return value.AsString()
+}
+
+// configInt is responsible for returning a number from the configuration of a
+// resource, or 0 when it isn't set or known yet.
+func configInt(value cty.Value) int {
+	if value.IsNull() || !value.IsKnown() {
+		return 0
+	}
+	i, _ := value.AsBigFloat().Int64()
+	return int(i)
+}
+
+// configServerGroup is responsible for naming a server group in the configuration
+// of a cluster by its services.
+func configServerGroup(server cty.Value) string {
+	var services []string
+	for _, service := range configElements(server.GetAttr("services")) {
+		if name := configString(service); name != "" {
+			services = append(services, name)
 		}
 	}
-	return nil
+	return servicesKey(services)
+}
+
+// servicesKey is responsible for building the key of a combination of services,
+// independent of the order they are listed in.
+func servicesKey(services []string) string {
+	sorted := append([]string(nil), services...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
 }
 
 func IsValidUUID(uuid string) bool {
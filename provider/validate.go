@@ -7,6 +7,153 @@ import (
 	couchbasecapella "github.com/couchbasecloud/couchbase-capella-api-go-client"
 )
 
+// allowedGcpRegionsEnumValues are the GCP regions hosted clusters can be deployed in,
+// which the Capella API client has no enum for.
+var allowedGcpRegionsEnumValues = []string{
+	"asia-east1",
+	"asia-east2",
+	"asia-northeast1",
+	"asia-northeast2",
+	"asia-northeast3",
+	"asia-south1",
+	"asia-south2",
+	"asia-southeast1",
+	"asia-southeast2",
+	"australia-southeast1",
+	"australia-southeast2",
+	"europe-central2",
+	"europe-north1",
+	"europe-west1",
+	"europe-west2",
+	"europe-west3",
+	"europe-west4",
+	"europe-west6",
+	"europe-west8",
+	"northamerica-northeast1",
+	"northamerica-northeast2",
+	"southamerica-east1",
+	"southamerica-west1",
+	"us-east1",
+	"us-east4",
+	"us-west1",
+	"us-west2",
+	"us-west3",
+	"us-west4",
+	"us-central1",
+	"us-central2",
+}
+
+// allowedGcpComputeEnumValues are the GCP compute instances of hosted clusters, which
+// the Capella API client has no enum for.
+var allowedGcpComputeEnumValues = []string{
+	"n2-standard-2",
+	"n2-standard-4",
+	"n2-standard-8",
+	"n2-standard-16",
+	"n2-standard-32",
+	"n2-standard-48",
+	"n2-standard-64",
+	"n2-standard-80",
+	"n2-highmem-2",
+	"n2-highmem-4",
+	"n2-highmem-8",
+	"n2-highmem-16",
+	"n2-highmem-32",
+	"n2-highmem-48",
+	"n2-highmem-64",
+	"n2-highmem-80",
+	"n2-highcpu-2",
+	"n2-highcpu-4",
+	"n2-highcpu-8",
+	"n2-highcpu-16",
+	"n2-highcpu-32",
+	"n2-highcpu-48",
+	"n2-highcpu-64",
+	"n2-highcpu-80",
+	"n2-custom-2-4096",
+	"n2-custom-4-8192",
+	"n2-custom-8-16384",
+	"n2-custom-16-32768",
+	"n2-custom-32-65536",
+	"n2-custom-36-73728",
+	"n2-custom-48-98304",
+	"n2-custom-72-147456",
+}
+
+// hostedStorageTypes are the storage types each cloud provider supports for hosted
+// clusters. The storage types of Azure disks aren't part of the Capella API client, so
+// Azure isn't restricted beyond the storage types the client knows.
+var hostedStorageTypes = map[string][]string{
+	string(couchbasecapella.V3PROVIDER_AWS): {string(couchbasecapella.V3STORAGETYPE_GP3), string(couchbasecapella.V3STORAGETYPE_IO2)},
+	string(couchbasecapella.V3PROVIDER_GCP): {string(couchbasecapella.V3STORAGETYPE_PD_SSD)},
+}
+
+// hostedIopsRanges are the minimum and maximum IOPS of each storage type that takes IOPS.
+var hostedIopsRanges = map[string][2]int{
+	string(couchbasecapella.V3STORAGETYPE_GP3): {3000, 16000},
+	string(couchbasecapella.V3STORAGETYPE_IO2): {1000, 64000},
+}
+
+// The types, storage backends and durability levels of buckets, which the Capella API
+// client has no enums for.
+const (
+	bucketTypeCouchbase = "couchbase"
+	bucketTypeEphemeral = "ephemeral"
+
+	bucketStorageCouchstore = "couchstore"
+	bucketStorageMagma      = "magma"
+
+	bucketDurabilityNone     = "none"
+	bucketDurabilityMajority = "majority"
+
+	// minMagmaMemoryQuota is the smallest memory quota in MiB of a bucket using magma.
+	minMagmaMemoryQuota = 1024
+	// maxBucketTTL is the largest max TTL in seconds of a bucket.
+	maxBucketTTL = 2147483647
+)
+
+var bucketTypes = []string{bucketTypeCouchbase, bucketTypeEphemeral}
+
+var bucketStorageBackends = []string{bucketStorageCouchstore, bucketStorageMagma}
+
+var bucketDurabilityLevels = []string{bucketDurabilityNone, bucketDurabilityMajority, "majorityAndPersistActive", "persistToMajority"}
+
+// ephemeralBucketDurabilityLevels are the durability levels of ephemeral buckets,
+// which can't wait for writes to be persisted to disk.
+var ephemeralBucketDurabilityLevels = []string{bucketDurabilityNone, bucketDurabilityMajority}
+
+// bucketEvictionPolicies are the eviction policies each type of bucket supports.
+var bucketEvictionPolicies = map[string][]string{
+	bucketTypeCouchbase: {"valueOnly", "fullEviction"},
+	bucketTypeEphemeral: {"noEviction", "nruEviction"},
+}
+
+// isValidRegion is responsible for checking that a region exists for a cloud provider.
+func isValidRegion(provider, region string) bool {
+	switch couchbasecapella.V3Provider(provider) {
+	case couchbasecapella.V3PROVIDER_AWS:
+		return couchbasecapella.AwsRegions(region).IsValid()
+	case couchbasecapella.V3PROVIDER_AZURE:
+		return couchbasecapella.AzureRegions(region).IsValid()
+	case couchbasecapella.V3PROVIDER_GCP:
+		return Has(allowedGcpRegionsEnumValues, region)
+	}
+	return false
+}
+
+// isValidCompute is responsible for checking that a compute instance exists for a cloud provider.
+func isValidCompute(provider, instance string) bool {
+	switch couchbasecapella.V3Provider(provider) {
+	case couchbasecapella.V3PROVIDER_AWS:
+		return couchbasecapella.AwsInstances(instance).IsValid()
+	case couchbasecapella.V3PROVIDER_AZURE:
+		return couchbasecapella.AzureInstances(instance).IsValid()
+	case couchbasecapella.V3PROVIDER_GCP:
+		return Has(allowedGcpComputeEnumValues, instance)
+	}
+	return false
+}
+
 func validateBucketName(val interface{}, key string) (warns []string, errs []error) {
 	var isStringAlphabetic = regexp.MustCompile(`^[a-zA-Z0-9-.]*$`).MatchString
 	var isAlphaNumeric = regexp.MustCompile(`^[a-zA-Z0-9]*$`).MatchString
@@ -36,6 +183,54 @@ func validateConflictResolution(val interface{}, key string) (warns []string, er
 	return
 }
 
+func validateBucketReplicas(val interface{}, key string) (warns []string, errs []error) {
+	replicas := val.(int)
+	if replicas < 0 || replicas > 3 {
+		errs = append(errs, fmt.Errorf(BucketInvalidReplicas, replicas))
+	}
+	return
+}
+
+func validateBucketDurabilityLevel(val interface{}, key string) (warns []string, errs []error) {
+	level := val.(string)
+	if !Has(bucketDurabilityLevels, level) {
+		errs = append(errs, fmt.Errorf(BucketInvalidDurabilityLevel, level))
+	}
+	return
+}
+
+func validateBucketEvictionPolicy(val interface{}, key string) (warns []string, errs []error) {
+	policy := val.(string)
+	if !Has(bucketEvictionPolicies[bucketTypeCouchbase], policy) && !Has(bucketEvictionPolicies[bucketTypeEphemeral], policy) {
+		errs = append(errs, fmt.Errorf(BucketInvalidEvictionPolicy, policy))
+	}
+	return
+}
+
+func validateBucketMaxTTL(val interface{}, key string) (warns []string, errs []error) {
+	ttl := val.(int)
+	if ttl < 0 || ttl > maxBucketTTL {
+		errs = append(errs, fmt.Errorf(BucketInvalidMaxTTL, ttl))
+	}
+	return
+}
+
+func validateBucketStorageBackend(val interface{}, key string) (warns []string, errs []error) {
+	backend := val.(string)
+	if !Has(bucketStorageBackends, backend) {
+		errs = append(errs, fmt.Errorf(BucketInvalidStorageBackend, backend))
+	}
+	return
+}
+
+func validateBucketType(val interface{}, key string) (warns []string, errs []error) {
+	bucketType := val.(string)
+	if !Has(bucketTypes, bucketType) {
+		errs = append(errs, fmt.Errorf(BucketInvalidType, bucketType))
+	}
+	return
+}
+
 func validateDatabaseUserPassword(val interface{}, key string) (warns []string, errs []error) {
 	password := val.(string)
 	passwordValidate := validatePassword(password)
@@ -76,49 +271,10 @@ func validateClusterName(val interface{}, key string) (warns []string, errs []er
 }
 
 func validateRegion(val interface{}, key string) (warns []string, errs []error) {
-	var allowedGcpRegionsEnumValues = []string{
-		"asia-east1",
-		"asia-east2",
-		"asia-northeast1",
-		"asia-northeast2",
-		"asia-northeast3",
-		"asia-south1",
-		"asia-south2",
-		"asia-southeast1",
-		"asia-southeast2",
-		"australia-southeast1",
-		"australia-southeast2",
-		"europe-central2",
-		"europe-north1",
-		"europe-west1",
-		"europe-west2",
-		"europe-west3",
-		"europe-west4",
-		"europe-west6",
-		"europe-west8",
-		"northamerica-northeast1",
-		"northamerica-northeast2",
-		"southamerica-east1",
-		"southamerica-west1",
-		"us-east1",
-		"us-east4",
-		"us-west1",
-		"us-west2",
-		"us-west3",
-		"us-west4",
-		"us-central1",
-		"us-central2",
-	}
-
 	region := val.(string)
-	awsRegionValidation := couchbasecapella.AwsRegions(region).IsValid()
-	azureRegionValidation := couchbasecapella.AzureRegions(region).IsValid()
-	gcpRegionValidation := false
-	for _, existing := range allowedGcpRegionsEnumValues {
-		if existing == region {
-			gcpRegionValidation = true
-		}
-	}
+	awsRegionValidation := isValidRegion(string(couchbasecapella.V3PROVIDER_AWS), region)
+	azureRegionValidation := isValidRegion(string(couchbasecapella.V3PROVIDER_AZURE), region)
+	gcpRegionValidation := isValidRegion(string(couchbasecapella.V3PROVIDER_GCP), region)
 
 	if !awsRegionValidation && !azureRegionValidation && !gcpRegionValidation {
 		errs = append(errs, fmt.Errorf(HostedClusterInvalidRegion, region))
@@ -163,50 +319,10 @@ func validateSize(val interface{}, key string) (warns []string, errs []error) {
 }
 
 func validateCompute(val interface{}, key string) (warns []string, errs []error) {
-	var allowedGcpComputeEnumValues = []string{
-		"n2-standard-2",
-		"n2-standard-4",
-		"n2-standard-8",
-		"n2-standard-16",
-		"n2-standard-32",
-		"n2-standard-48",
-		"n2-standard-64",
-		"n2-standard-80",
-		"n2-highmem-2",
-		"n2-highmem-4",
-		"n2-highmem-8",
-		"n2-highmem-16",
-		"n2-highmem-32",
-		"n2-highmem-48",
-		"n2-highmem-64",
-		"n2-highmem-80",
-		"n2-highcpu-2",
-		"n2-highcpu-4",
-		"n2-highcpu-8",
-		"n2-highcpu-16",
-		"n2-highcpu-32",
-		"n2-highcpu-48",
-		"n2-highcpu-64",
-		"n2-highcpu-80",
-		"n2-custom-2-4096",
-		"n2-custom-4-8192",
-		"n2-custom-8-16384",
-		"n2-custom-16-32768",
-		"n2-custom-32-65536",
-		"n2-custom-36-73728",
-		"n2-custom-48-98304",
-		"n2-custom-72-147456",
-	}
-
 	instance := val.(string)
-	awsInstanceValidation := couchbasecapella.AwsInstances(instance).IsValid()
-	azureInstanceValidation := couchbasecapella.AzureInstances(instance).IsValid()
-	gcpInstanceValidation := false
-	for _, existing := range allowedGcpComputeEnumValues {
-		if existing == instance {
-			gcpInstanceValidation = true
-		}
-	}
+	awsInstanceValidation := isValidCompute(string(couchbasecapella.V3PROVIDER_AWS), instance)
+	azureInstanceValidation := isValidCompute(string(couchbasecapella.V3PROVIDER_AZURE), instance)
+	gcpInstanceValidation := isValidCompute(string(couchbasecapella.V3PROVIDER_GCP), instance)
 
 	if !awsInstanceValidation && !azureInstanceValidation && !gcpInstanceValidation {
 		errs = append(errs, fmt.Errorf(HostedClusterInvalidCompute, instance))
@@ -234,8 +350,10 @@ func validateStorageType(val interface{}, key string) (warns []string, errs []er
 
 func validateIops(val interface{}, key string) (warns []string, errs []error) {
 	iops := val.(int)
-	GP3IopsIsValid := iops >= 3000 && iops <= 16000
-	IO2IopsIsValid := iops >= 1000 && iops <= 64000
+	gp3 := hostedIopsRanges[string(couchbasecapella.V3STORAGETYPE_GP3)]
+	io2 := hostedIopsRanges[string(couchbasecapella.V3STORAGETYPE_IO2)]
+	GP3IopsIsValid := iops >= gp3[0] && iops <= gp3[1]
+	IO2IopsIsValid := iops >= io2[0] && iops <= io2[1]
 	if !GP3IopsIsValid && !IO2IopsIsValid {
 		errs = append(errs, fmt.Errorf(HostedClusterInvalidIOPS))
 	}
@@ -286,3 +404,20 @@ func validateAzureVolume(val interface{}, key string) (warns []string, errs []er
 	}
 	return
 }
+
+func validateGcpInstance(val interface{}, key string) (warns []string, errs []error) {
+	instance := val.(string)
+	if !isValidCompute(string(couchbasecapella.V3PROVIDER_GCP), instance) {
+		errs = append(errs, fmt.Errorf(VpcClusterInvalidGcpInstance, instance))
+	}
+	return
+}
+
+func validateGcpDiskSize(val interface{}, key string) (warns []string, errs []error) {
+	size := val.(int)
+	sizeIsValid := size >= 50 && size <= 16000
+	if !sizeIsValid {
+		errs = append(errs, fmt.Errorf(ClusterInvalidStorageSize, size))
+	}
+	return
+}